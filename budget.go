@@ -0,0 +1,75 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"golang.org/x/net/context"
+)
+
+// The remaining baggage budget is carried on the context by this key.
+type budgetKey struct{}
+
+// WithBudget returns a context carrying a budget of n bytes for optional
+// baggage. Each hop may spend from the budget with TryAddOptional.
+func WithBudget(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, budgetKey{}, n)
+}
+
+// Budget returns the remaining optional baggage budget on a context, if any.
+func Budget(ctx context.Context) (n int, ok bool) {
+	n, ok = ctx.Value(budgetKey{}).(int)
+	return n, ok
+}
+
+// TryAddOptional adds a baggage property only if the growth in its serialized
+// size fits within the remaining budget, returning a context with the property
+// added and the budget reduced accordingly. The size is that of the header
+// line SerializeHeaders would write for the stored value, after any join with
+// a prior value, including the header prefix in context and any escaping of
+// the value, as counted by SerializedSize; a prior value's line is already
+// paid for. If the key is sealed, the property does not fit, or there is no
+// budget on the context, the context is returned unchanged and ok is false.
+func TryAddOptional(ctx context.Context, key, value string) (context.Context, bool) {
+	n, ok := Budget(ctx)
+	if !ok {
+		return ctx, false
+	}
+	bkey := baggageKey(canonicalKey(key))
+	if isSealed(ctx, bkey) {
+		return ctx, false
+	}
+	prefix := headerPrefix(ctx, "")
+	next := withBaggage(ctx, bkey, value)
+	size := 0
+	if stored, ok := lookup(next, bkey); ok {
+		size = headerSize(prefix, string(bkey), stored)
+	}
+	if prior, ok := lookup(ctx, bkey); ok {
+		size -= headerSize(prefix, string(bkey), prior)
+	}
+	if size < 0 {
+		size = 0
+	}
+	if size > n {
+		return ctx, false
+	}
+	return WithBudget(next, n-size), true
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestTryAddOptional(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBudget(ctx, 38)
+
+	ctx, ok := TryAddOptional(ctx, "tenant", "acme")
+	assert.True(t, ok)
+	n, _ := Budget(ctx)
+	assert.Equal(t, 20, n)
+
+	ctx, ok = TryAddOptional(ctx, "region", "us-east")
+	assert.False(t, ok, "exceeds remaining budget")
+	n, _ = Budget(ctx)
+	assert.Equal(t, 20, n)
+
+	ctx, ok = TryAddOptional(ctx, "zone", "a")
+	assert.True(t, ok)
+	n, _ = Budget(ctx)
+	assert.Equal(t, 7, n)
+
+	tenant, _ := Baggage(ctx, "tenant")
+	assert.Equal(t, "acme", tenant)
+	_, ok = Baggage(ctx, "region")
+	assert.False(t, ok)
+}
+
+func TestTryAddOptionalWithoutBudget(t *testing.T) {
+	ctx, ok := TryAddOptional(context.Background(), "tenant", "acme")
+	assert.False(t, ok)
+	_, ok = Baggage(ctx, "tenant")
+	assert.False(t, ok)
+}
+
+func TestTryAddOptionalMatchesSerializedSize(t *testing.T) {
+	ctx := WithBudget(context.Background(), 100)
+	ctx, ok := TryAddOptional(ctx, "Tenant", "acme, inc.")
+	assert.True(t, ok)
+	n, _ := Budget(ctx)
+	assert.Equal(t, 100-SerializedSize(ctx, ""), n)
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	var buf bytes.Buffer
+	assert.NoError(t, h.Write(&buf))
+	assert.Equal(t, 100-buf.Len(), n)
+}
+
+func TestTryAddOptionalCountsEscaping(t *testing.T) {
+	ctx := WithBudget(context.Background(), 20)
+	_, ok := TryAddOptional(ctx, "note", "a,b,c,d")
+	assert.False(t, ok, "fits raw but not once escaped")
+
+	ctx = WithBudget(context.Background(), 25)
+	ctx, ok = TryAddOptional(ctx, "note", "a,b,c,d")
+	assert.True(t, ok)
+	n, _ := Budget(ctx)
+	assert.Equal(t, 0, n)
+}
+
+func TestTryAddOptionalCountsPrefix(t *testing.T) {
+	ctx := WithBudget(context.Background(), 18)
+	ctx = WithHeaderPrefix(ctx, "x-baggage-")
+	_, ok := TryAddOptional(ctx, "tenant", "acme")
+	assert.False(t, ok)
+}
+
+func TestTryAddOptionalSealed(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "abc")
+	ctx = WithBudget(ctx, 100)
+	out, ok := TryAddOptional(ctx, RequestIDKey, "def")
+	assert.False(t, ok)
+	assert.Equal(t, ctx, out)
+	id, _ := RequestID(out)
+	assert.Equal(t, "abc", id)
+}
+
+func TestTryAddOptionalChargesJoinedValue(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBudget(ctx, 100)
+	before := SerializedSize(ctx, "")
+	ctx, ok := TryAddOptional(ctx, "Receipts", "bob")
+	assert.True(t, ok)
+	receipts, _ := Baggage(ctx, "Receipts")
+	assert.Equal(t, "alice, bob", receipts)
+	n, _ := Budget(ctx)
+	assert.Equal(t, 100-(SerializedSize(ctx, "")-before), n, "only the growth of the joined value is charged")
+}
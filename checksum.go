@@ -0,0 +1,92 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ChecksumKey names the header, after the header prefix, that carries a
+// checksum of the other baggage headers.
+const ChecksumKey = "checksum"
+
+// ErrChecksumMismatch is returned by DeserializeVerify when the baggage
+// headers do not match their checksum, because an intermediary stripped or
+// altered some of them.
+var ErrChecksumMismatch = errors.New("openctx: baggage headers do not match their checksum")
+
+// SerializeWithChecksum writes baggage onto HTTP headers like SerializeHeaders
+// with the configured or default prefix, and also writes a checksum header,
+// named by the prefix and ChecksumKey, over all of the baggage headers.
+func SerializeWithChecksum(ctx context.Context, h http.Header) {
+	SerializeHeaders(ctx, h, "")
+	prefix := headerPrefix(ctx, "")
+	h.Set(prefix+ChecksumKey, baggageChecksum(h, prefix))
+}
+
+// DeserializeVerify reads baggage from HTTP headers like DeserializeHeaders
+// with the configured or default prefix, after checking the headers against
+// the checksum written by SerializeWithChecksum. If the checksum does not
+// match, or is missing while baggage headers are present, it returns the
+// context unchanged and ErrChecksumMismatch. The checksum header is not read
+// as baggage.
+func DeserializeVerify(ctx context.Context, h http.Header) (context.Context, error) {
+	prefix := headerPrefix(ctx, "")
+	name := http.CanonicalHeaderKey(prefix + ChecksumKey)
+	baggage := make(http.Header, len(h))
+	for key, values := range h {
+		if http.CanonicalHeaderKey(key) != name {
+			baggage[key] = values
+		}
+	}
+	checksum := h.Get(name)
+	want := baggageChecksum(baggage, prefix)
+	if checksum == "" && want == baggageChecksum(nil, prefix) {
+		return ctx, nil
+	}
+	if checksum != want {
+		return ctx, ErrChecksumMismatch
+	}
+	return DeserializeHeaders(ctx, baggage, prefix), nil
+}
+
+// baggageChecksum returns a checksum over the baggage headers with a prefix,
+// other than the checksum header itself, in sorted key order and without
+// regard to the case of header names.
+func baggageChecksum(h http.Header, prefix string) string {
+	var lines []string
+	for name, values := range h {
+		key, ok := headerKey(name, prefix)
+		if !ok || key == canonicalKey(ChecksumKey) {
+			continue
+		}
+		lines = append(lines, key+": "+strings.Join(values, ",")+"\n")
+	}
+	sort.Strings(lines)
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(strings.Join(lines, ""))))
+}
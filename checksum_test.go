@@ -0,0 +1,83 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func checksumHeaders() http.Header {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	ctx = WithBaggage(ctx, "Note", "a, b")
+	h := http.Header{}
+	h.Set("Content-Type", "text/plain")
+	SerializeWithChecksum(ctx, h)
+	return h
+}
+
+func TestDeserializeVerifyIntact(t *testing.T) {
+	h := checksumHeaders()
+	assert.NotEmpty(t, h.Get("Ctx-Checksum"))
+
+	ctx, err := DeserializeVerify(context.Background(), h)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"note", "region", "tenant"}, Keys(ctx))
+	note, _ := Baggage(ctx, "Note")
+	assert.Equal(t, "a, b", note)
+
+	h.Set("X-Unrelated", "added by a proxy")
+	_, err = DeserializeVerify(context.Background(), h)
+	assert.NoError(t, err, "other headers do not matter")
+}
+
+func TestDeserializeVerifyTampered(t *testing.T) {
+	for name, tamper := range map[string]func(http.Header){
+		"stripped": func(h http.Header) { h.Del("Ctx-Region") },
+		"altered":  func(h http.Header) { h.Set("Ctx-Tenant", "evil") },
+		"added":    func(h http.Header) { h.Set("Ctx-Debug", "true") },
+		"checksum": func(h http.Header) { h.Del("Ctx-Checksum") },
+	} {
+		h := checksumHeaders()
+		tamper(h)
+		ctx, err := DeserializeVerify(context.Background(), h)
+		assert.Equal(t, ErrChecksumMismatch, err, name)
+		assert.False(t, HasBaggage(ctx), name)
+	}
+}
+
+func TestDeserializeVerifyEmpty(t *testing.T) {
+	h := http.Header{}
+	SerializeWithChecksum(context.Background(), h)
+	ctx, err := DeserializeVerify(context.Background(), h)
+	assert.NoError(t, err)
+	assert.False(t, HasBaggage(ctx))
+
+	_, err = DeserializeVerify(context.Background(), http.Header{})
+	assert.NoError(t, err)
+}
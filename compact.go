@@ -0,0 +1,60 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"golang.org/x/net/context"
+)
+
+// Compact returns a context carrying the same baggage and removals as a
+// context, flattened into a single node atop it. Each WithBaggage adds a node
+// to the context chain, so baggage lookups on a context that has passed
+// through many hops walk a long chain; on a compacted context they take one
+// step. Join functions and other context values are still found by walking
+// the original chain.
+func Compact(ctx context.Context) context.Context {
+	order, _ := ctx.Value(orderKey{}).([]baggageKey)
+	values := make(map[baggageKey]interface{}, len(order))
+	for _, bkey := range order {
+		values[bkey] = ctx.Value(bkey)
+	}
+	return &compactContext{Context: ctx, values: values, order: order}
+}
+
+// A compactContext answers lookups of baggage from a map of the keys in the
+// order of the context it compacts. Every key a context has ever carried
+// baggage for is in its order, so a baggage key missing from the map is
+// absent rather than left to the chain.
+type compactContext struct {
+	context.Context
+	values map[baggageKey]interface{}
+	order  []baggageKey
+}
+
+func (c *compactContext) Value(key interface{}) interface{} {
+	switch key := key.(type) {
+	case baggageKey:
+		return c.values[key]
+	case orderKey:
+		return c.order
+	}
+	return c.Context.Value(key)
+}
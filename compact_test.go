@@ -0,0 +1,90 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+type compactTestKey struct{}
+
+func TestCompact(t *testing.T) {
+	ctx := context.WithValue(context.Background(), compactTestKey{}, "kept")
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "charlie")
+	ctx = WithTTL(ctx, time.Second)
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = WithEphemeralBaggage(ctx, "Scratch", "x")
+	ctx = Remove(ctx, "Zone")
+
+	compacted := Compact(ctx)
+	assert.Equal(t, Dump(ctx), Dump(compacted))
+	assert.Equal(t, Keys(ctx), Keys(compacted))
+	assert.Equal(t, []string{"zone"}, TombstonedKeys(compacted))
+	assert.Equal(t, "kept", compacted.Value(compactTestKey{}))
+	_, ok := Baggage(compacted, "Missing")
+	assert.False(t, ok)
+
+	compacted = WithReceipt(compacted, "alice")
+	assert.Equal(t, []string{"alice", "charlie"}, Receipts(compacted))
+	compacted = WithBaggage(compacted, "Zone", "b")
+	zone, _ := Baggage(compacted, "Zone")
+	assert.Equal(t, "b", zone)
+}
+
+func TestCompactCarriedKeysOnly(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		learnKey(baggageKey(fmt.Sprintf("compact-known-%d", i)))
+	}
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = Remove(ctx, "Zone")
+
+	compacted := Compact(ctx).(*compactContext)
+	assert.Len(t, compacted.values, 2)
+	assert.Equal(t, []string{"tenant"}, Keys(compacted))
+	assert.Equal(t, []string{"zone"}, TombstonedKeys(compacted))
+}
+
+func BenchmarkCompact(b *testing.B) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	for i := 0; i < 500; i++ {
+		ctx = WithBaggage(ctx, fmt.Sprintf("hop-%d", i%10), "value")
+	}
+
+	b.Run("deep", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Baggage(ctx, "Tenant")
+		}
+	})
+	compacted := Compact(ctx)
+	b.Run("compacted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Baggage(compacted, "Tenant")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+
+	"golang.org/x/net/context"
+)
+
+// Compressed baggage begins with a format byte, followed by the
+// length-prefixed encoding from Encode, either as is or compressed with gzip.
+const (
+	formatPlain byte = 0
+	formatGzip  byte = 1
+)
+
+// CompressionThreshold is the size of encoded baggage, in bytes, below which
+// MarshalCompressed does not compress, since gzip overhead would outweigh any
+// savings.
+const CompressionThreshold = 256
+
+// ErrUnknownFormat is returned by UnmarshalCompressed for an unrecognized
+// format byte.
+var ErrUnknownFormat = errors.New("openctx: unknown compressed baggage format")
+
+// MarshalCompressed returns the baggage carried by a context in the
+// length-prefixed encoding, compressed with gzip if the encoding is at least
+// CompressionThreshold bytes.
+func MarshalCompressed(ctx context.Context) ([]byte, error) {
+	var plain bytes.Buffer
+	plain.WriteByte(formatPlain)
+	if err := Encode(ctx, &plain); err != nil {
+		return nil, err
+	}
+	if plain.Len()-1 < CompressionThreshold {
+		return plain.Bytes(), nil
+	}
+	var compressed bytes.Buffer
+	compressed.WriteByte(formatGzip)
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(plain.Bytes()[1:]); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// UnmarshalCompressed returns a context carrying the baggage from the output
+// of MarshalCompressed, joining each value with any prior value through the
+// join functions in context. On any error, no baggage is added.
+func UnmarshalCompressed(ctx context.Context, b []byte) (context.Context, error) {
+	if len(b) == 0 {
+		return ctx, io.ErrUnexpectedEOF
+	}
+	switch b[0] {
+	case formatPlain:
+		return Decode(ctx, bytes.NewReader(b[1:]))
+	case formatGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(b[1:]))
+		if err != nil {
+			return ctx, err
+		}
+		defer zr.Close()
+		return Decode(ctx, zr)
+	}
+	return ctx, ErrUnknownFormat
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestMarshalCompressedSmall(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithTTL(ctx, time.Second)
+
+	b, err := MarshalCompressed(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, formatPlain, b[0])
+
+	out, err := UnmarshalCompressed(context.Background(), b)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"receipts", "ttl"}, Keys(out))
+	assert.Equal(t, []string{"alice"}, Receipts(out))
+}
+
+func TestMarshalCompressedLarge(t *testing.T) {
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		ctx = WithReceipt(ctx, fmt.Sprintf("service-%03d", i))
+	}
+
+	b, err := MarshalCompressed(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, formatGzip, b[0])
+	receipts, _ := Baggage(ctx, "receipts")
+	assert.True(t, len(b) < len(receipts), "compressed to %d bytes from %d", len(b), len(receipts))
+
+	out, err := UnmarshalCompressed(context.Background(), b)
+	assert.NoError(t, err)
+	assert.Equal(t, Receipts(ctx), Receipts(out))
+}
+
+func TestUnmarshalCompressedErrors(t *testing.T) {
+	_, err := UnmarshalCompressed(context.Background(), nil)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = UnmarshalCompressed(context.Background(), []byte{9, 0})
+	assert.Equal(t, ErrUnknownFormat, err)
+	_, err = UnmarshalCompressed(context.Background(), []byte{formatGzip, 1, 2, 3})
+	assert.Error(t, err)
+
+	base := WithBaggage(context.Background(), "Tenant", "acme")
+	out, err := UnmarshalCompressed(base, []byte{formatPlain, 2, 1, 'a', 1, '1', 1})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, base, out, "no baggage is added on error")
+}
@@ -40,6 +40,8 @@ package openctx
 import (
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"golang.org/x/net/context"
 )
@@ -55,74 +57,521 @@ type joinKey string
 // keys to converge globally on a small set. We determine which keys are on a
 // context by enumerating all known keys and filtering for the keys actually
 // encountered on the context.
-var knownKeys map[baggageKey]struct{}
+// Contexts are shared freely between goroutines, so the known keys are guarded
+// by a lock. Keys are learned rarely and read often.
+var (
+	knownKeysMu sync.RWMutex
+	knownKeys   map[baggageKey]struct{}
+)
 
 func learnKey(key baggageKey) {
+	knownKeysMu.RLock()
+	_, ok := knownKeys[key]
+	knownKeysMu.RUnlock()
+	if ok {
+		return
+	}
+	knownKeysMu.Lock()
 	if knownKeys == nil {
 		knownKeys = make(map[baggageKey]struct{}, 10)
 	}
 	knownKeys[key] = struct{}{}
+	knownKeysMu.Unlock()
+}
+
+// learnedKeys returns a copy of the known keys, for callers that may learn new
+// keys while enumerating them.
+func learnedKeys() []baggageKey {
+	knownKeysMu.RLock()
+	defer knownKeysMu.RUnlock()
+	bkeys := make([]baggageKey, 0, len(knownKeys))
+	for bkey := range knownKeys {
+		bkeys = append(bkeys, bkey)
+	}
+	return bkeys
+}
+
+// KnownKeys returns the sorted baggage key names this process has learned from
+// any context, for diagnosing misspelled or duplicated keys. Keys are learned
+// when written or given a join function, and never forgotten. Use Keys for the
+// keys carried by a particular context.
+func KnownKeys() []string {
+	bkeys := learnedKeys()
+	keys := make([]string, len(bkeys))
+	for i, bkey := range bkeys {
+		keys[i] = string(bkey)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// The key canonicalizer is stored atomically, so that it may be read by any
+// number of goroutines.
+var keyCanonicalizer atomic.Value
+
+// SetKeyCanonicalizer replaces the function that canonicalizes baggage key
+// names, which by default is strings.ToLower. Every function that accepts a
+// key name canonicalizes it first. The canonicalizer should be set once, at
+// init time, before any baggage is written; keys written under one
+// canonicalizer may not be found under another. Setting nil restores the
+// default.
+func SetKeyCanonicalizer(fn func(string) string) {
+	if fn == nil {
+		fn = strings.ToLower
+	}
+	keyCanonicalizer.Store(fn)
+}
+
+// Keys are canonicalized unless this is on.
+var assumeCanonical atomicFlag
+
+// AssumeCanonicalKeys turns off canonicalizing key names when on, for hot
+// paths whose keys are all already canonical, such as lowercase constants.
+// It is off by default.
+//
+// Warning: with this on, every key name passed to this package must already
+// be in canonical form, as the key canonicalizer would return it. A key in any
+// other form is silently treated as a distinct key, so "Tenant" would not find
+// baggage written as "tenant", and neither would survive serialization as
+// expected.
+func AssumeCanonicalKeys(on bool) {
+	assumeCanonical.set(on)
+}
+
+func canonicalKey(key string) string {
+	if assumeCanonical.on() {
+		return key
+	}
+	if fn, ok := keyCanonicalizer.Load().(func(string) string); ok {
+		return fn(key)
+	}
+	return strings.ToLower(key)
 }
 
 // WithBaggage adds a baggage value for a key and returns a new context,
 // joining the value with any prior known value, or taking the latter if there
 // is no appropriate joiner in context.
+//
+// WithBaggage has no effect on keys that have been sealed with Seal.
 func WithBaggage(ctx context.Context, key, value string) context.Context {
-	key = strings.ToLower(key)
-	bkey := baggageKey(key)
-	jkey := joinKey(key)
-	join := ctx.Value(jkey)
-	if join != nil {
-		return withBaggageJoin(ctx, bkey, value, join.(func(a, b string) string))
-	}
-	learnKey(bkey)
-	return context.WithValue(ctx, bkey, value)
+	return withBaggage(ctx, baggageKey(canonicalKey(key)), value)
 }
 
 func withBaggage(ctx context.Context, bkey baggageKey, value string) context.Context {
-	jkey := joinKey(bkey)
-	join := ctx.Value(jkey)
-	if join != nil {
-		return withBaggageJoin(ctx, bkey, value, join.(func(a, b string) string))
+	if isSealed(ctx, bkey) {
+		return ctx
+	}
+	if join := joinFor(ctx, bkey); join != nil {
+		return withBaggageJoin(ctx, bkey, value, join)
 	}
-	return context.WithValue(ctx, bkey, value)
+	return setBaggage(ctx, bkey, value)
 }
 
 // WithBaggageJoin either adds or merges a baggage value with a given join
 // function and returns a new context.
 func WithBaggageJoin(ctx context.Context, key, value string, join func(a, b string) string) context.Context {
-	bkey := baggageKey(strings.ToLower(key))
+	bkey := baggageKey(canonicalKey(key))
 	return withBaggageJoin(ctx, bkey, value, join)
 }
 
+// An empty join result is stored as a value, rather than removing the key,
+// when this is on.
+var keepEmptyJoins atomicFlag
+
+// KeepEmptyJoinResults chooses what happens when a join function returns the
+// empty string. By default the key is removed, as by Remove, since an empty
+// result usually means that a join function resolved a conflict by dropping
+// the value, and an empty value would still be serialized. With keep on, the
+// empty string is stored as the value, as for any other result.
+func KeepEmptyJoinResults(keep bool) {
+	keepEmptyJoins.set(keep)
+}
+
+// removesKey reports whether a join result removes its key.
+func removesKey(joined string) bool {
+	return joined == "" && !keepEmptyJoins.on()
+}
+
+// withOwnJoin adds a baggage value merged by a join function that belongs to
+// the key, introducing it as the join function for the key if the context has
+// none, so that later writes and joined contexts merge the key the same way.
+func withOwnJoin(ctx context.Context, key, value string, join func(a, b string) string) context.Context {
+	key = canonicalKey(key)
+	if ctx.Value(joinKey(key)) == nil {
+		ctx = WithJoin(ctx, key, join)
+	}
+	return withBaggageJoin(ctx, baggageKey(key), value, join)
+}
+
 // The internal withBaggageJoin method accepts the typed baggage key and
 // returns a new context with the joined baggage.
 func withBaggageJoin(ctx context.Context, bkey baggageKey, value string, join func(a, b string) string) context.Context {
-	prior := ctx.Value(bkey)
-	if prior != nil {
-		value = join(prior.(string), value)
+	if isSealed(ctx, bkey) {
+		return ctx
+	}
+	if prior, ok := lookup(ctx, bkey); ok {
+		value = callJoin(bkey, join, prior, value)
+		joined(bkey)
+		joins := joinCount(ctx, bkey) + 1
+		if removesKey(value) {
+			return &removedContext{ctx, map[baggageKey]struct{}{bkey: {}}, joins}
+		}
+		learnKey(bkey)
+		return storeJoined(ctx, bkey, value, joins)
 	}
+	return setBaggage(ctx, bkey, value)
+}
+
+// The order in which keys are first set is tracked on each context by an
+// order key, carrying a slice of baggage keys that is never modified once it
+// is on a context.
+type orderKey struct{}
+
+// All baggage is stored through setBaggage, which learns the key globally and
+// records the key in the context's order if it is new to the context.
+func setBaggage(ctx context.Context, bkey baggageKey, value string) context.Context {
 	learnKey(bkey)
-	return context.WithValue(ctx, bkey, value)
+	return storeBaggage(ctx, bkey, value)
+}
+
+// storeBaggage stores baggage like setBaggage without learning the key, which
+// then is found only through the context's order.
+func storeBaggage(ctx context.Context, bkey baggageKey, value string) context.Context {
+	return storeJoined(ctx, bkey, value, 0)
+}
+
+// storeJoined stores baggage like storeBaggage, recording on the new context
+// how many joins have merged the value.
+func storeJoined(ctx context.Context, bkey baggageKey, value string, joins int) context.Context {
+	checkCollision(ctx, bkey)
+	if ctx.Value(bkey) == nil {
+		order, _ := ctx.Value(orderKey{}).([]baggageKey)
+		next := make([]baggageKey, len(order), len(order)+1)
+		copy(next, order)
+		ctx = context.WithValue(ctx, orderKey{}, append(next, bkey))
+	}
+	return &valueContext{Context: ctx, bkey: bkey, value: value, joins: joins}
 }
 
 // Baggage returns the value for a given baggage key.
 func Baggage(ctx context.Context, key string) (value string, ok bool) {
-	bkey := baggageKey(strings.ToLower(key))
-	bval := ctx.Value(bkey)
-	if bval != nil {
-		return bval.(string), true
+	return lookup(ctx, baggageKey(canonicalKey(key)))
+}
+
+// Has reports whether a baggage key carries a live value in context. A key
+// that has been removed is not present.
+func Has(ctx context.Context, key string) bool {
+	_, ok := lookup(ctx, baggageKey(canonicalKey(key)))
+	return ok
+}
+
+// BaggageEntry returns the value for a given baggage key along with whether
+// the key has a join function in context, for serializers that need both.
+func BaggageEntry(ctx context.Context, key string) (value string, joinable, ok bool) {
+	bkey := baggageKey(canonicalKey(key))
+	value, ok = lookup(ctx, bkey)
+	if !ok {
+		return "", false, false
+	}
+	return value, ctx.Value(joinKey(bkey)) != nil, true
+}
+
+// lookup returns the value for a baggage key, if the key carries a value on
+// the context that has neither been removed nor expired.
+func lookup(ctx context.Context, bkey baggageKey) (value string, ok bool) {
+	return liveValue(ctx.Value(bkey))
+}
+
+// liveValue returns the baggage value stored under a baggage key, if it is a
+// value that has not expired rather than a tombstone.
+func liveValue(v interface{}) (value string, ok bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case expiringValue:
+		if now().Before(v.expiry) {
+			return v.value, true
+		}
 	}
 	return "", false
 }
 
+// A removed baggage property is masked by a tombstone carried on the context
+// map under its baggage key.
+type tombstone struct{}
+
+// tombstonedKeys returns the keys set on a context that have since been
+// removed, in the order they were first set.
+func tombstonedKeys(ctx context.Context) []baggageKey {
+	order, _ := ctx.Value(orderKey{}).([]baggageKey)
+	var bkeys []baggageKey
+	for _, bkey := range order {
+		if _, ok := ctx.Value(bkey).(tombstone); ok {
+			bkeys = append(bkeys, bkey)
+		}
+	}
+	return bkeys
+}
+
+// TombstonedKeys returns the sorted baggage key names that were set on a
+// context and have since been removed.
+func TombstonedKeys(ctx context.Context) []string {
+	keys := []string{}
+	for _, bkey := range tombstonedKeys(ctx) {
+		keys = append(keys, string(bkey))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Remove returns a context that no longer carries baggage for a key. Removing
+// a key that has been sealed with Seal has no effect.
+func Remove(ctx context.Context, key string) context.Context {
+	return RemoveAll(ctx, key)
+}
+
+// RemoveAll returns a context that no longer carries baggage for any of the
+// given keys, masking them all with a single context rather than one per key.
+// Removing keys that have been sealed with Seal has no effect.
+func RemoveAll(ctx context.Context, keys ...string) context.Context {
+	var bkeys map[baggageKey]struct{}
+	for _, key := range keys {
+		bkey := baggageKey(canonicalKey(key))
+		if _, ok := lookup(ctx, bkey); !ok || isSealed(ctx, bkey) {
+			continue
+		}
+		if bkeys == nil {
+			bkeys = make(map[baggageKey]struct{}, len(keys))
+		}
+		bkeys[bkey] = struct{}{}
+	}
+	if bkeys == nil {
+		return ctx
+	}
+	return &removedContext{ctx, bkeys, 0}
+}
+
+// StripAll returns a context that carries no baggage, masking every key with
+// a single context. Deadlines, cancellation, join functions, and other values
+// are unaffected. Keys sealed with Seal are not removed.
+func StripAll(ctx context.Context) context.Context {
+	return RemoveAll(ctx, Keys(ctx)...)
+}
+
+// Project returns a context that carries baggage only for the given keys, as
+// the inverse of RemoveAll. Unlike RemoveAll, it hides every other key rather
+// than masking it with a tombstone, so the dropped keys are not reported by
+// TombstonedKeys or serialized as tombstones, and keys sealed with Seal are
+// dropped too. A kept key keeps its seal, provenance, and hop limit.
+// Deadlines, cancellation, join functions, and other values are unaffected.
+func Project(ctx context.Context, keys ...string) context.Context {
+	keep := make(map[baggageKey]struct{}, 2*len(keys))
+	for _, key := range keys {
+		bkey := baggageKey(canonicalKey(key))
+		keep[bkey] = struct{}{}
+		keep[baggageKey(hopKeyPrefix)+bkey] = struct{}{}
+	}
+	var order []baggageKey
+	prior, _ := ctx.Value(orderKey{}).([]baggageKey)
+	for _, bkey := range prior {
+		if _, ok := keep[bkey]; !ok {
+			continue
+		}
+		if _, ok := lookup(ctx, bkey); ok {
+			order = append(order, bkey)
+		} else {
+			delete(keep, bkey)
+		}
+	}
+	return &projectedContext{ctx, keep, order}
+}
+
+// A projectedContext hides the baggage for every key but those it keeps,
+// along with the seals and provenance of the hidden keys.
+type projectedContext struct {
+	context.Context
+	keep  map[baggageKey]struct{}
+	order []baggageKey
+}
+
+func (c *projectedContext) kept(bkey baggageKey) bool {
+	_, ok := c.keep[bkey]
+	return ok
+}
+
+func (c *projectedContext) Value(key interface{}) interface{} {
+	switch key := key.(type) {
+	case baggageKey:
+		if !c.kept(key) {
+			return nil
+		}
+	case orderKey:
+		return c.order
+	case sealKey:
+		if !c.kept(baggageKey(key)) {
+			return nil
+		}
+	case provenanceKey:
+		if !c.kept(baggageKey(key)) {
+			return nil
+		}
+	case joinCountKey:
+		if !c.kept(baggageKey(key)) {
+			return nil
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// A removedContext masks the baggage for a set of keys with tombstones. When a
+// join removed the key, joins counts the joins that merged it.
+type removedContext struct {
+	context.Context
+	bkeys map[baggageKey]struct{}
+	joins int
+}
+
+func (c *removedContext) Value(key interface{}) interface{} {
+	switch key := key.(type) {
+	case baggageKey:
+		if _, ok := c.bkeys[key]; ok {
+			return tombstone{}
+		}
+	case joinCountKey:
+		if _, ok := c.bkeys[baggageKey(key)]; ok && c.joins > 0 {
+			return c.joins
+		}
+	}
+	return c.Context.Value(key)
+}
+
 // Keys returns the baggage key names carried by a context.
 // This method is intended for exclusively for the use of baggage serializers.
 func Keys(ctx context.Context) []string {
-	keys := []string{}
+	if c, ok := ctx.(*valueContext); ok {
+		return c.keys()
+	}
+	keys, _ := scanKeys(ctx)
+	return keys
+}
+
+// KeysMatching returns the sorted baggage key names carried by a context that
+// match a pattern: a pattern ending in '*' matches every key with the prefix
+// before it, and any other pattern matches its key exactly.
+func KeysMatching(ctx context.Context, pattern string) []string {
+	prefix := strings.TrimSuffix(pattern, "*")
+	wildcard := len(prefix) < len(pattern)
+	prefix = canonicalKey(prefix)
+	matches := []string{}
+	for _, key := range Keys(ctx) {
+		if key == prefix || (wildcard && strings.HasPrefix(key, prefix)) {
+			matches = append(matches, key)
+		}
+	}
+	return matches
+}
+
+// scanKeys returns the sorted baggage key names carried by a context, and
+// whether they may be cached on the context, which they may not if any value
+// has an expiry.
+func scanKeys(ctx context.Context) (keys []string, cacheable bool) {
+	keys = []string{}
+	cacheable = true
+	learned, unlearned := keySets(ctx)
+	for _, bkey := range learned {
+		v := ctx.Value(bkey)
+		if _, ok := v.(expiringValue); ok {
+			cacheable = false
+		}
+		if _, ok := liveValue(v); ok {
+			keys = append(keys, string(bkey))
+		}
+	}
+	for _, bkey := range unlearned {
+		if _, ok := lookup(ctx, bkey); ok {
+			keys = append(keys, string(bkey))
+		}
+	}
+	sort.Strings(keys)
+	return keys, cacheable
+}
+
+// keySets returns the keys to probe for baggage on a context: those known
+// globally, and those in the context's order that are not, having been stored
+// by WithEphemeralBaggage. Both are copied under a single hold of knownKeysMu,
+// and the context is not probed while it is held, since probing a lazily
+// joined context calls join functions and hooks, which may learn keys.
+func keySets(ctx context.Context) (learned, unlearned []baggageKey) {
+	order, _ := ctx.Value(orderKey{}).([]baggageKey)
+	knownKeysMu.RLock()
+	defer knownKeysMu.RUnlock()
+	learned = make([]baggageKey, 0, len(knownKeys))
 	for bkey := range knownKeys {
-		val := ctx.Value(bkey)
-		if val != nil {
+		learned = append(learned, bkey)
+	}
+	for _, bkey := range order {
+		if _, ok := knownKeys[bkey]; !ok {
+			unlearned = append(unlearned, bkey)
+		}
+	}
+	return learned, unlearned
+}
+
+// UnionKeys returns the sorted union of the baggage key names carried by any
+// of the given contexts.
+func UnionKeys(ctxs ...context.Context) []string {
+	keys := []string{}
+	seen := make(map[baggageKey]struct{})
+	for _, ctx := range ctxs {
+		learned, unlearned := keySets(ctx)
+		for _, bkey := range append(learned, unlearned...) {
+			if _, ok := seen[bkey]; ok {
+				continue
+			}
+			if _, ok := lookup(ctx, bkey); ok {
+				seen[bkey] = struct{}{}
+				keys = append(keys, string(bkey))
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// KeysOrdered returns the baggage key names carried by a context in the order
+// they were first set. Keys merged in by Join follow in no particular order.
+func KeysOrdered(ctx context.Context) []string {
+	order, _ := ctx.Value(orderKey{}).([]baggageKey)
+	keys := make([]string, 0, len(order))
+	for _, bkey := range order {
+		if _, ok := lookup(ctx, bkey); ok {
+			keys = append(keys, string(bkey))
+		}
+	}
+	return keys
+}
+
+// JoinableKeys returns the sorted baggage key names carried by a context that
+// also have a join function in context. A response serializer may send back
+// only these keys, since the caller can merge them.
+func JoinableKeys(ctx context.Context) []string {
+	keys := []string{}
+	for _, bkey := range learnedKeys() {
+		if _, ok := lookup(ctx, bkey); ok && ctx.Value(joinKey(bkey)) != nil {
+			keys = append(keys, string(bkey))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// JoinKeys returns the sorted baggage key names that have a join function in
+// context, whether introduced by WithJoin or WithJoinMap, regardless of
+// whether the context carries baggage for them.
+func JoinKeys(ctx context.Context) []string {
+	keys := []string{}
+	for _, bkey := range learnedKeys() {
+		if ctx.Value(joinKey(bkey)) != nil {
 			keys = append(keys, string(bkey))
 		}
 	}
@@ -130,22 +579,266 @@ func Keys(ctx context.Context) []string {
 	return keys
 }
 
+// HasBaggage returns whether a context carries any baggage at all. Unlike
+// Keys, it consults only the keys set on the context rather than all known
+// keys, stops at the first live property, and does not allocate.
+func HasBaggage(ctx context.Context) bool {
+	order, _ := ctx.Value(orderKey{}).([]baggageKey)
+	for _, bkey := range order {
+		if _, ok := lookup(ctx, bkey); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // WithJoin introduces a join function for a baggage property in the current
 // context.  This would typically be called by an RPC library to ensure that
 // keys with known semantics merge properly from subsequent response contexts.
 func WithJoin(ctx context.Context, key string, join func(a, b string) string) context.Context {
-	jkey := joinKey(strings.ToLower(key))
-	return context.WithValue(ctx, jkey, join)
+	key = canonicalKey(key)
+	learnKey(baggageKey(key))
+	if ctx.Value(joinNameKey(key)) != nil {
+		ctx = context.WithValue(ctx, joinNameKey(key), "")
+	}
+	c := &joinContext{Context: ctx, bkey: baggageKey(key), join: join}
+	c.ident = c
+	return c
+}
+
+// Each introduction of a join function has an identity, answered for a join
+// identity key, so that contexts can tell whether they found a join function
+// through the same introduction. Functions themselves cannot be compared.
+type joinIdentKey string
+
+// A joinContext carries the join function introduced for a key.
+type joinContext struct {
+	context.Context
+	bkey  baggageKey
+	join  func(a, b string) string
+	ident interface{}
+}
+
+func (c *joinContext) Value(key interface{}) interface{} {
+	switch key := key.(type) {
+	case joinKey:
+		if baggageKey(key) == c.bkey {
+			return c.join
+		}
+	case joinIdentKey:
+		if baggageKey(key) == c.bkey {
+			return c.ident
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// WithJoinMap introduces join functions for several baggage properties at
+// once, in a single context. Join functions are found by the nearest
+// introduction, so between WithJoin and WithJoinMap for the same key, the
+// later call wins.
+func WithJoinMap(ctx context.Context, joins map[string]func(a, b string) string) context.Context {
+	jkeys := make(map[joinKey]func(a, b string) string, len(joins))
+	for key, join := range joins {
+		key = canonicalKey(key)
+		learnKey(baggageKey(key))
+		jkeys[joinKey(key)] = join
+	}
+	return &joinMapContext{ctx, jkeys}
+}
+
+// A joinMapContext carries a map of join functions.
+type joinMapContext struct {
+	context.Context
+	joins map[joinKey]func(a, b string) string
+}
+
+func (c *joinMapContext) Value(key interface{}) interface{} {
+	switch key := key.(type) {
+	case joinKey:
+		if join, ok := c.joins[key]; ok {
+			return join
+		}
+	case joinNameKey:
+		if _, ok := c.joins[joinKey(key)]; ok {
+			return ""
+		}
+	case joinIdentKey:
+		if _, ok := c.joins[joinKey(key)]; ok {
+			return c
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// A default join function for keys that have none of their own is carried on
+// the context by this key.
+type defaultJoinKey struct{}
+
+// WithDefaultJoin introduces a join function for every baggage property that
+// has no join function of its own in context, in place of taking the later
+// value. A join function introduced for a key by WithJoin or WithJoinMap
+// always takes precedence over the default, wherever it was introduced.
+// Setting nil restores taking the later value.
+func WithDefaultJoin(ctx context.Context, join func(a, b string) string) context.Context {
+	return context.WithValue(ctx, defaultJoinKey{}, join)
+}
+
+// joinFor returns the join function for a baggage key in context, or the
+// default join function, or nil if there is neither.
+func joinFor(ctx context.Context, bkey baggageKey) func(a, b string) string {
+	if join, ok := ctx.Value(joinKey(bkey)).(func(a, b string) string); ok {
+		return join
+	}
+	join, _ := ctx.Value(defaultJoinKey{}).(func(a, b string) string)
+	return join
+}
+
+// The join hook is stored atomically, so that it may be read by any number of
+// goroutines.
+var joinHook atomic.Value
+
+// SetJoinHook installs a hook that is called once at the end of each Join with
+// the sorted keys that were merged from the later context and those of them
+// that conflicted, as reported by JoinReport. This is intended for auditing
+// merges. Setting nil removes the hook.
+func SetJoinHook(fn func(merged, conflicts []string)) {
+	joinHook.Store(fn)
 }
 
 // Join two contexts, using given merge functions for known keys, otherwise
 // taking baggage from the later context when there are conflicts.
+//
+// Join does not overwrite keys sealed on this context with Seal.
+//
+// Joining is only independent of order for keys whose join functions are
+// commutative and associative, like taking the minimum of TTLs or the union of
+// receipts. Such keys converge on the same value regardless of the order in
+// which parallel responses are joined. Keys without a join function in context
+// are order dependent: the last joined context wins.
+//
+// Join never modifies either context. Each merged property is layered onto a
+// new context derived from this context, so the joined contexts remain safe to
+// read concurrently.
 func Join(this context.Context, that context.Context) context.Context {
-	for bkey := range knownKeys {
-		val := that.Value(bkey)
-		if val != nil {
-			this = withBaggage(this, bkey, val.(string))
+	if fn, _ := joinHook.Load().(func(merged, conflicts []string)); fn != nil {
+		merged, conflicts := joinSummary(this, that)
+		defer fn(merged, conflicts)
+	}
+	if !HasBaggage(that) {
+		return this
+	}
+	return joinBaggage(this, that)
+}
+
+func joinBaggage(this context.Context, that context.Context) context.Context {
+	learned, ephemeral := keySets(that)
+	for _, bkey := range learned {
+		if val, ok := lookup(that, bkey); ok {
+			this = withBaggage(this, bkey, val)
+			this = joinProvenance(this, that, bkey)
+		}
+	}
+	for _, bkey := range ephemeral {
+		if val, ok := lookup(that, bkey); ok {
+			this = withEphemeralBaggage(this, bkey, val)
 		}
 	}
 	return this
 }
+
+// JoinReport joins two contexts like Join and also reports the sorted keys
+// whose values conflicted: keys without a join function in this context for
+// which that context carried a different value, overwriting the value of this
+// context.
+func JoinReport(this context.Context, that context.Context) (merged context.Context, conflicts []string) {
+	_, conflicts = joinSummary(this, that)
+	return Join(this, that), conflicts
+}
+
+// JoinMaps folds the baggage from each of a sequence of maps, such as results
+// collected from parallel workers, into a context with WithBaggage, joining
+// values through the join functions in context. Keys without a join function
+// take their value from the last map that carries them.
+func JoinMaps(ctx context.Context, maps ...map[string]string) context.Context {
+	for _, m := range maps {
+		for key, value := range m {
+			ctx = WithBaggage(ctx, key, value)
+		}
+	}
+	return ctx
+}
+
+// joinSummary returns the sorted keys that joining that context into this
+// context merges, and those of them that conflict, as reported by JoinReport.
+func joinSummary(this context.Context, that context.Context) (merged []string, conflicts []string) {
+	merged = []string{}
+	conflicts = []string{}
+	for _, key := range Keys(that) {
+		bkey := baggageKey(key)
+		if isSealed(this, bkey) {
+			continue
+		}
+		merged = append(merged, key)
+		if joinFor(this, bkey) != nil {
+			continue
+		}
+		prior, ok := lookup(this, bkey)
+		if value, _ := lookup(that, bkey); ok && prior != value {
+			conflicts = append(conflicts, key)
+		}
+	}
+	return merged, conflicts
+}
+
+// JoinWithFuncs joins two contexts like Join, but first carries over the join
+// functions that the later context introduced with WithJoin, so that both this
+// and subsequent merges on the returned context use them. Where both contexts
+// have a join function for the same key, the join function of this context
+// takes precedence.
+func JoinWithFuncs(this context.Context, that context.Context) context.Context {
+	for _, bkey := range learnedKeys() {
+		jkey := joinKey(bkey)
+		if this.Value(jkey) != nil {
+			continue
+		}
+		join := that.Value(jkey)
+		if join != nil {
+			this = context.WithValue(this, jkey, join)
+		}
+	}
+	return Join(this, that)
+}
+
+// CopyBaggage applies the baggage carried by src onto dst with WithBaggage,
+// using the join functions of dst, and returns the resulting context. No other
+// values, deadline, or cancellation carry over from src, so this can seed a
+// fresh context, like one for a background job, with a request's baggage.
+func CopyBaggage(dst, src context.Context) context.Context {
+	for _, key := range Keys(src) {
+		value, _ := Baggage(src, key)
+		dst = WithBaggage(dst, key, value)
+	}
+	return dst
+}
+
+// JoinAll joins each of the given contexts into this context in order, as
+// though by successive calls to Join.
+func JoinAll(this context.Context, those ...context.Context) context.Context {
+	for _, that := range those {
+		this = Join(this, that)
+	}
+	return this
+}
+
+// Go runs a function in a new goroutine with the given context and returns a
+// channel that receives the context the function returns. This supports
+// fanning out parallel requests, where the caller is responsible for joining
+// each response context with Join as it arrives.
+func Go(ctx context.Context, fn func(ctx context.Context) context.Context) <-chan context.Context {
+	done := make(chan context.Context, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	return done
+}
@@ -226,3 +226,564 @@ func TestCharlieCallsEveryone(t *testing.T) {
 	ctx = WithTTL(ctx, time.Second)
 	ctx = charlie(ctx, t)
 }
+
+// Charlie relies on the joined receipts and TTL being the same regardless of
+// the order in which parallel responses arrive. Join functions for both are
+// commutative and associative, so every permutation of JoinAll must agree.
+
+func permutations(ctxs []context.Context) [][]context.Context {
+	if len(ctxs) <= 1 {
+		return [][]context.Context{ctxs}
+	}
+	var perms [][]context.Context
+	for i := range ctxs {
+		rest := make([]context.Context, 0, len(ctxs)-1)
+		rest = append(rest, ctxs[:i]...)
+		rest = append(rest, ctxs[i+1:]...)
+		for _, perm := range permutations(rest) {
+			perms = append(perms, append([]context.Context{ctxs[i]}, perm...))
+		}
+	}
+	return perms
+}
+
+func TestJoinAllOrderIndependentForJoinableKeys(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithTTL(ctx, time.Second)
+
+	branches := []context.Context{
+		WithTTL(alice(ctx), 500*time.Millisecond),
+		WithTTL(danny(ctx), 200*time.Millisecond),
+		WithTTL(elizabeth(ctx), 700*time.Millisecond),
+		bob(ctx),
+	}
+
+	perms := permutations(branches)
+	assert.Len(t, perms, 24)
+	for _, perm := range perms {
+		joined := JoinAll(ctx, perm...)
+		assert.Equal(t, []string{"alice", "bob", "danny", "elizabeth"}, Receipts(joined))
+		ttl, ok := TTL(joined)
+		assert.True(t, ok)
+		assert.Equal(t, 200*time.Millisecond, ttl)
+	}
+}
+
+func TestJoinAllLastWinsForOtherKeys(t *testing.T) {
+	ctx := context.Background()
+	ctxA := WithBaggage(ctx, "Shard", "a")
+	ctxB := WithBaggage(ctx, "Shard", "b")
+
+	shard, _ := Baggage(JoinAll(ctx, ctxA, ctxB), "Shard")
+	assert.Equal(t, "b", shard)
+	shard, _ = Baggage(JoinAll(ctx, ctxB, ctxA), "Shard")
+	assert.Equal(t, "a", shard)
+}
+
+func TestHasBaggage(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, HasBaggage(ctx))
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	assert.False(t, HasBaggage(ctx), "join functions are not baggage")
+	ctx = WithReceipt(ctx, "alice")
+	assert.True(t, HasBaggage(ctx))
+	assert.False(t, HasBaggage(Remove(ctx, "receipts")), "removed baggage does not count")
+}
+
+// A callee may introduce a join function that its caller lacks. Joining the
+// response with JoinWithFuncs carries that join function back to the caller.
+
+func TestJoinWithFuncsCarriesCalleeJoin(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tags", "a")
+
+	callee := WithJoin(ctx, "Tags", joinReceipts)
+	callee = WithBaggage(callee, "Tags", "b")
+
+	joined := JoinWithFuncs(ctx, callee)
+	tags, _ := Baggage(joined, "Tags")
+	assert.Equal(t, "a, b", tags)
+	joined = WithBaggage(joined, "Tags", "c")
+	tags, _ = Baggage(joined, "Tags")
+	assert.Equal(t, "a, b, c", tags, "later merges use the callee join")
+
+	joined = Join(ctx, callee)
+	joined = WithBaggage(joined, "Tags", "c")
+	tags, _ = Baggage(joined, "Tags")
+	assert.Equal(t, "c", tags, "plain join drops the callee join")
+}
+
+func TestJoinWithFuncsPrefersBaseJoin(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "Tags", func(a, b string) string { return a })
+	ctx = WithBaggage(ctx, "Tags", "a")
+
+	callee := WithJoin(ctx, "Tags", joinReceipts)
+	callee = WithBaggage(callee, "Tags", "b")
+
+	joined := JoinWithFuncs(ctx, callee)
+	tags, _ := Baggage(joined, "Tags")
+	assert.Equal(t, "a", tags)
+}
+
+func TestJoinableKeys(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithJoin(ctx, "unused", joinReceipts)
+	ctx = WithTTL(ctx, time.Second)
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBaggage(ctx, "Shard", "a")
+	assert.Equal(t, []string{"receipts", "shard", "ttl"}, Keys(ctx))
+	assert.Equal(t, []string{"receipts", "ttl"}, JoinableKeys(ctx))
+}
+
+// Go codifies charlie's fan-out and fan-in, running each request in its own
+// goroutine and joining the response contexts as they arrive.
+
+func TestGoFanOutJoin(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithTTL(ctx, time.Second)
+	ctx = WithReceipt(ctx, "charlie")
+
+	responses := []<-chan context.Context{
+		Go(ctx, alice),
+		Go(ctx, danny),
+		Go(ctx, elizabeth),
+		Go(ctx, func(ctx context.Context) context.Context {
+			return WithTTL(ctx, 100*time.Millisecond)
+		}),
+	}
+	for _, response := range responses {
+		ctx = Join(ctx, <-response)
+	}
+
+	assert.Equal(t, []string{"alice", "bob", "charlie", "danny", "elizabeth"}, Receipts(ctx))
+	ttl, ok := TTL(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 100*time.Millisecond, ttl)
+}
+
+// Joining a context into another must not modify it, since response contexts
+// may be read concurrently, for example by a logger, while they are joined.
+
+func TestJoinDoesNotModifySource(t *testing.T) {
+	base := context.Background()
+	base = WithJoin(base, "receipts", joinReceipts)
+	base = WithReceipt(base, "charlie")
+
+	source := context.Background()
+	source = WithReceipt(source, "alice")
+	source = WithTTL(source, time.Second)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			receipts, _ := Baggage(source, "receipts")
+			assert.Equal(t, "alice", receipts)
+			assert.Equal(t, []string{"receipts", "ttl"}, Keys(source))
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		joined := Join(base, source)
+		assert.Equal(t, []string{"alice", "charlie"}, Receipts(joined))
+	}
+	<-done
+
+	assert.Equal(t, []string{"charlie"}, Receipts(base))
+	assert.Equal(t, []string{"alice"}, Receipts(source))
+}
+
+func TestKeysOrdered(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, []string{}, KeysOrdered(ctx))
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBaggage(ctx, "Account", "42")
+	ctx = WithReceipt(ctx, "bob")
+	ctx = WithBaggage(ctx, "Zone", "b")
+	assert.Equal(t, []string{"zone", "receipts", "account"}, KeysOrdered(ctx))
+	assert.Equal(t, []string{"account", "receipts", "zone"}, Keys(ctx))
+}
+
+// A partner canonicalizes keys by trimming whitespace and collapsing
+// underscores to dashes.
+
+func partnerKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	return strings.Replace(key, "_", "-", -1)
+}
+
+func TestSetKeyCanonicalizer(t *testing.T) {
+	SetKeyCanonicalizer(partnerKey)
+	defer SetKeyCanonicalizer(nil)
+
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, " Request_ID ", "42")
+	id, ok := Baggage(ctx, "request-id")
+	assert.True(t, ok)
+	assert.Equal(t, "42", id)
+	id, ok = Baggage(ctx, "REQUEST_ID")
+	assert.True(t, ok)
+	assert.Equal(t, "42", id)
+	assert.Equal(t, []string{"request-id"}, Keys(ctx))
+}
+
+func TestKeysMatching(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "App-Tenant", "acme")
+	ctx = WithBaggage(ctx, "App-Region", "us-east")
+	ctx = WithBaggage(ctx, "App", "api")
+	ctx = WithBaggage(ctx, "Token", "secret")
+
+	assert.Equal(t, []string{"app-tenant"}, KeysMatching(ctx, "App-Tenant"))
+	assert.Equal(t, []string{}, KeysMatching(ctx, "App-"), "exact without a wildcard")
+	assert.Equal(t, []string{"app-region", "app-tenant"}, KeysMatching(ctx, "App-*"))
+	assert.Equal(t, []string{"app", "app-region", "app-tenant"}, KeysMatching(ctx, "app*"))
+	assert.Equal(t, []string{"app", "app-region", "app-tenant", "token"}, KeysMatching(ctx, "*"))
+	assert.Equal(t, []string{}, KeysMatching(ctx, "Zone*"))
+	assert.Equal(t, []string{}, KeysMatching(context.Background(), "*"))
+}
+
+func TestAssumeCanonicalKeys(t *testing.T) {
+	AssumeCanonicalKeys(true)
+	defer AssumeCanonicalKeys(false)
+
+	ctx := WithBaggage(context.Background(), "tenant", "acme")
+	tenant, ok := Baggage(ctx, "tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+	_, ok = Baggage(ctx, "Tenant")
+	assert.False(t, ok, "keys are taken as given")
+
+	AssumeCanonicalKeys(false)
+	_, ok = Baggage(ctx, "Tenant")
+	assert.True(t, ok)
+}
+
+// Hot paths whose keys are canonical constants can skip canonicalizing them.
+// Under a canonicalizer that builds a new string, such as one that namespaces
+// keys, each canonicalization allocates; strings.ToLower does not allocate for
+// a key that is already lowercase, so the default saves only time.
+
+func namespacedKey(key string) string {
+	return "app-" + strings.TrimPrefix(strings.ToLower(key), "app-")
+}
+
+func BenchmarkCanonicalKeys(b *testing.B) {
+	for _, bc := range []struct {
+		name          string
+		canonicalizer func(string) string
+		key           string
+	}{
+		{"lowercase", nil, "region"},
+		{"namespaced", namespacedKey, "app-region"},
+	} {
+		SetKeyCanonicalizer(bc.canonicalizer)
+		ctx := WithBaggage(context.Background(), "tenant", "acme")
+		run := func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Baggage(WithBaggage(ctx, bc.key, "us-east"), bc.key)
+			}
+		}
+		b.Run(bc.name+"/canonicalized", run)
+		AssumeCanonicalKeys(true)
+		b.Run(bc.name+"/assumed", run)
+		AssumeCanonicalKeys(false)
+	}
+	SetKeyCanonicalizer(nil)
+}
+
+// In many fan-ins a branch adds no baggage of its own. Join returns early
+// rather than probing the branch for every known key.
+
+func BenchmarkJoinEmpty(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		learnKey(baggageKey(fmt.Sprintf("bench-%d", i)))
+	}
+	ctx = WithReceipt(ctx, "charlie")
+	ctx = WithTTL(ctx, time.Second)
+	empty := context.Background()
+
+	b.Run("fast path", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Join(ctx, empty)
+		}
+	})
+	b.Run("probe all keys", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			joinBaggage(ctx, empty)
+		}
+	})
+}
+
+func TestCopyBaggage(t *testing.T) {
+	type requestValue struct{}
+	src, cancel := context.WithCancel(context.Background())
+	src = context.WithValue(src, requestValue{}, "request only")
+	src = WithReceipt(src, "alice")
+	src = WithTTL(src, time.Second)
+	cancel()
+
+	dst := context.WithValue(context.Background(), requestValue{}, "job")
+	dst = WithJoin(dst, "receipts", joinReceipts)
+	dst = WithReceipt(dst, "job")
+	dst = CopyBaggage(dst, src)
+
+	assert.Equal(t, []string{"receipts", "ttl"}, Keys(dst))
+	assert.Equal(t, []string{"alice", "job"}, Receipts(dst))
+	ttl, _ := TTL(dst)
+	assert.Equal(t, time.Second, ttl)
+	assert.Equal(t, "job", dst.Value(requestValue{}))
+	assert.NoError(t, dst.Err())
+}
+
+func TestJoinReport(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "charlie")
+	ctx = WithBaggage(ctx, "Shard", "a")
+	ctx = WithBaggage(ctx, "Zone", "east")
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+
+	response := WithReceipt(ctx, "alice")
+	response = WithBaggage(response, "Shard", "b")
+	response = WithBaggage(response, "Zone", "west")
+	response = WithBaggage(response, "Region", "us")
+
+	merged, conflicts := JoinReport(ctx, response)
+	assert.Equal(t, []string{"shard", "zone"}, conflicts)
+	assert.Equal(t, []string{"alice", "charlie"}, Receipts(merged))
+	shard, _ := Baggage(merged, "Shard")
+	assert.Equal(t, "b", shard)
+
+	_, conflicts = JoinReport(ctx, ctx)
+	assert.Equal(t, []string{}, conflicts)
+}
+
+// A conflict resolver drops the value when branches disagree.
+func joinAgreed(a, b string) string {
+	if a != b {
+		return ""
+	}
+	return a
+}
+
+func TestEmptyJoinRemovesKey(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Shard", joinAgreed)
+	ctx = WithBaggage(ctx, "Shard", "a")
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	agreed := Join(ctx, WithBaggage(ctx, "Shard", "a"))
+	shard, ok := Baggage(agreed, "Shard")
+	assert.True(t, ok)
+	assert.Equal(t, "a", shard)
+
+	branch := WithBaggage(context.Background(), "Shard", "b")
+	for _, merged := range []context.Context{
+		WithBaggage(ctx, "Shard", "b"),
+		Join(ctx, branch),
+		JoinLazy(ctx, branch),
+	} {
+		_, ok = Baggage(merged, "Shard")
+		assert.False(t, ok)
+		assert.Equal(t, []string{"tenant"}, Keys(merged))
+		assert.Equal(t, []string{"shard"}, TombstonedKeys(merged))
+	}
+}
+
+func TestKeepEmptyJoinResults(t *testing.T) {
+	KeepEmptyJoinResults(true)
+	defer KeepEmptyJoinResults(false)
+
+	ctx := WithJoin(context.Background(), "Shard", joinAgreed)
+	ctx = WithBaggage(ctx, "Shard", "a")
+	branch := WithBaggage(context.Background(), "Shard", "b")
+	for _, merged := range []context.Context{
+		WithBaggage(ctx, "Shard", "b"),
+		Join(ctx, branch),
+		JoinLazy(ctx, branch),
+	} {
+		shard, ok := Baggage(merged, "Shard")
+		assert.True(t, ok)
+		assert.Equal(t, "", shard)
+	}
+}
+
+func TestJoinMaps(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithBaggage(ctx, "TTL", "500")
+
+	ctx = JoinMaps(ctx,
+		map[string]string{"Receipts": "alice", "TTL": "300", "Shard": "a"},
+		map[string]string{"Receipts": "bob, carol", "TTL": "100"},
+		map[string]string{"Receipts": "alice, dave", "TTL": "200", "Shard": "c"},
+	)
+	assert.Equal(t, []string{"alice", "bob", "carol", "dave"}, Receipts(ctx))
+	ttl, _ := TTL(ctx)
+	assert.Equal(t, 100*time.Millisecond, ttl)
+	shard, _ := Baggage(ctx, "Shard")
+	assert.Equal(t, "c", shard)
+
+	assert.Equal(t, ctx, JoinMaps(ctx))
+}
+
+func TestJoinHook(t *testing.T) {
+	type call struct{ merged, conflicts []string }
+	var calls []call
+	SetJoinHook(func(merged, conflicts []string) {
+		calls = append(calls, call{merged, conflicts})
+	})
+	defer SetJoinHook(nil)
+
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "charlie")
+	ctx = WithBaggage(ctx, "Shard", "a")
+
+	first := WithReceipt(ctx, "alice")
+	first = WithBaggage(first, "Shard", "b")
+	second := WithBaggage(ctx, "Region", "us")
+
+	merged := Join(Join(ctx, first), second)
+	assert.Equal(t, []string{"alice", "charlie"}, Receipts(merged))
+	assert.Equal(t, []call{
+		{[]string{"receipts", "shard"}, []string{"shard"}},
+		{[]string{"receipts", "region", "shard"}, []string{"shard"}},
+	}, calls)
+
+	calls = nil
+	JoinReport(ctx, context.Background())
+	assert.Equal(t, []call{{[]string{}, []string{}}}, calls, "once per join, even with nothing to merge")
+}
+
+func TestBaggageEntry(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBaggage(ctx, "Shard", "a")
+
+	value, joinable, ok := BaggageEntry(ctx, "Receipts")
+	assert.Equal(t, "alice", value)
+	assert.True(t, joinable)
+	assert.True(t, ok)
+
+	value, joinable, ok = BaggageEntry(ctx, "Shard")
+	assert.Equal(t, "a", value)
+	assert.False(t, joinable)
+	assert.True(t, ok)
+
+	value, joinable, ok = BaggageEntry(ctx, "Absent")
+	assert.Equal(t, "", value)
+	assert.False(t, joinable)
+	assert.False(t, ok)
+}
+
+func TestUnionKeys(t *testing.T) {
+	ctxA := WithBaggage(context.Background(), "Zone", "a")
+	ctxA = WithReceipt(ctxA, "alice")
+	ctxB := WithBaggage(context.Background(), "Zone", "b")
+	ctxB = WithBaggage(ctxB, "Tenant", "acme")
+	ctxC := Remove(WithBaggage(context.Background(), "Shard", "c"), "Shard")
+
+	assert.Equal(t, []string{"receipts", "tenant", "zone"}, UnionKeys(ctxA, ctxB, ctxC))
+	assert.Equal(t, []string{}, UnionKeys())
+}
+
+func TestWithJoinMap(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoinMap(ctx, map[string]func(a, b string) string{
+		"TTL":      joinTTL,
+		"Receipts": joinReceipts,
+	})
+	ctx = WithTTL(ctx, time.Second)
+	ctx = WithReceipt(ctx, "charlie")
+
+	ctx = JoinAll(ctx, alice(ctx), WithTTL(danny(ctx), 100*time.Millisecond))
+	assert.Equal(t, []string{"alice", "bob", "charlie", "danny"}, Receipts(ctx))
+	ttl, _ := TTL(ctx)
+	assert.Equal(t, 100*time.Millisecond, ttl)
+	assert.Equal(t, []string{"receipts", "ttl"}, JoinableKeys(ctx))
+}
+
+func TestWithJoinMapPrecedence(t *testing.T) {
+	first := func(a, b string) string { return a }
+	last := func(a, b string) string { return b }
+
+	ctx := WithJoin(context.Background(), "Shard", first)
+	ctx = WithJoinMap(ctx, map[string]func(a, b string) string{"Shard": last})
+	ctx = WithBaggage(WithBaggage(ctx, "Shard", "a"), "Shard", "b")
+	shard, _ := Baggage(ctx, "Shard")
+	assert.Equal(t, "b", shard, "the later map wins")
+
+	ctx = WithJoinMap(context.Background(), map[string]func(a, b string) string{"Shard": last})
+	ctx = WithJoin(ctx, "Shard", first)
+	ctx = WithBaggage(WithBaggage(ctx, "Shard", "a"), "Shard", "b")
+	shard, _ = Baggage(ctx, "Shard")
+	assert.Equal(t, "a", shard, "the later join wins")
+}
+
+func TestHas(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	assert.True(t, Has(ctx, "Tenant"))
+	assert.True(t, Has(ctx, "tenant"))
+	assert.False(t, Has(ctx, "Region"))
+
+	ctx = Remove(ctx, "Tenant")
+	assert.False(t, Has(ctx, "Tenant"))
+}
+
+func TestJoinKeys(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, []string{}, JoinKeys(ctx))
+
+	ctx = WithJoin(ctx, "TTL", joinTTL)
+	ctx = WithJoinMap(ctx, map[string]func(a, b string) string{"Receipts": joinReceipts})
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	assert.Equal(t, []string{"receipts", "ttl"}, JoinKeys(ctx))
+}
+
+func TestWithDefaultJoin(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithDefaultJoin(ctx, JoinUnion(","))
+	ctx = WithJoin(ctx, "TTL", joinTTL)
+	ctx = WithBaggage(ctx, "Tags", "a")
+	ctx = WithBaggage(ctx, "Tags", "c")
+	ctx = WithTTL(ctx, time.Second)
+
+	branch := WithBaggage(ctx, "Tags", "b")
+	branch = WithTTL(branch, 100*time.Millisecond)
+	ctx = Join(ctx, branch)
+
+	tags, _ := Baggage(ctx, "Tags")
+	assert.Equal(t, "a,b,c", tags, "an unregistered key merges through the default")
+	ttl, _ := TTL(ctx)
+	assert.Equal(t, 100*time.Millisecond, ttl, "a key's own join function takes precedence")
+
+	ctx = WithDefaultJoin(ctx, nil)
+	ctx = WithBaggage(ctx, "Tags", "d")
+	tags, _ = Baggage(ctx, "Tags")
+	assert.Equal(t, "d", tags)
+}
+
+func TestKnownKeys(t *testing.T) {
+	a := WithBaggage(context.Background(), "Known-A", "1")
+	b := WithBaggage(context.Background(), "Known-B", "2")
+	WithJoin(context.Background(), "Known-C", JoinMax)
+	Remove(a, "Known-A")
+
+	known := KnownKeys()
+	assert.True(t, sort.StringsAreSorted(known))
+	assert.Subset(t, known, []string{"known-a", "known-b", "known-c"})
+	assert.Equal(t, []string{"known-b"}, Keys(b))
+}
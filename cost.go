@@ -0,0 +1,52 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// CostKey is the baggage key for the work units counted by WithCost.
+const CostKey = "cost"
+
+// WithCost adds units of work to the cost carried by a context, joined by
+// JoinSum so that joining parallel branches adds their costs. Each branch
+// carries the cost it inherited, which a join would count once per branch, so
+// a context must carry no cost when it forks branches to be joined back; zero
+// it first with Remove(ctx, CostKey).
+func WithCost(ctx context.Context, units int64) context.Context {
+	return withOwnJoin(ctx, CostKey, strconv.FormatInt(units, 10), JoinSum)
+}
+
+// Cost returns the units of work counted by WithCost.
+func Cost(ctx context.Context) (int64, bool) {
+	value, ok := Baggage(ctx, CostKey)
+	if !ok {
+		return 0, false
+	}
+	units, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return units, true
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestCost(t *testing.T) {
+	ctx := context.Background()
+	_, ok := Cost(ctx)
+	assert.False(t, ok)
+
+	ctx = WithCost(ctx, 0)
+	branches := []context.Context{
+		WithCost(ctx, 3),
+		WithCost(WithCost(ctx, 2), 2),
+		WithCost(ctx, 5),
+	}
+	for _, perm := range permutations(branches) {
+		cost, ok := Cost(JoinAll(ctx, perm...))
+		assert.True(t, ok)
+		assert.Equal(t, int64(12), cost)
+	}
+}
+
+func TestCostZeroedBeforeFork(t *testing.T) {
+	ctx := WithCost(context.Background(), 10)
+	fork := Remove(ctx, CostKey)
+	a := WithCost(fork, 3)
+	b := WithCost(fork, 5)
+	cost, ok := Cost(JoinAll(ctx, a, b))
+	assert.True(t, ok)
+	assert.Equal(t, int64(18), cost)
+}
+
+func TestCostFromPeer(t *testing.T) {
+	ctx := DeserializeHeaders(context.Background(), http.Header{"Ctx-Cost": {"12"}}, "")
+	ctx = WithCost(ctx, 1)
+	ctx = WithCost(ctx, 1)
+	cost, ok := Cost(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, int64(14), cost)
+	value, _ := Baggage(ctx, CostKey)
+	assert.Equal(t, "14", value, "the wire value does not grow")
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"log"
+	"os"
+
+	"golang.org/x/net/context"
+)
+
+// Baggage keys have their own unexported type, so they never collide with
+// values that other packages put on a context. A common mistake, though, is
+// to put what was meant to be baggage on a context with context.WithValue and
+// a plain string key, where openctx will never find it. Debug mode reports
+// writes to baggage keys that have such a shadow value.
+
+// Debug mode starts on if the OPENCTX_DEBUG environment variable is set.
+var debug atomicFlag
+
+func init() {
+	debug.set(os.Getenv("OPENCTX_DEBUG") != "")
+}
+
+// SetDebug turns debug mode on or off. In debug mode, writing baggage for a
+// key logs a warning if the context also carries a value under the same name
+// as a plain string key. Debug mode is off by default, and costs nothing when
+// off.
+func SetDebug(on bool) {
+	debug.set(on)
+}
+
+// checkCollision warns, in debug mode, when a plain string context key
+// shadows the baggage key about to be written.
+func checkCollision(ctx context.Context, bkey baggageKey) {
+	if !debug.on() {
+		return
+	}
+	if val := ctx.Value(string(bkey)); val != nil {
+		log.Printf("openctx: writing baggage %q, but the context also carries a %T under the plain string key %q, which is not baggage", string(bkey), val, string(bkey))
+	}
+}
+
+// AdoptValue is an interoperability shim for code that puts what is meant to
+// be baggage on a context with context.WithValue and a plain string key. If
+// the context carries a string under the plain string key, AdoptValue adds it
+// as baggage for the same key, joining it with any prior value, and returns
+// the new context. Otherwise the context is returned unchanged. Prefer fixing
+// such code to write baggage with WithBaggage.
+func AdoptValue(ctx context.Context, key string) context.Context {
+	value, ok := ctx.Value(key).(string)
+	if !ok {
+		return ctx
+	}
+	return WithBaggage(ctx, key, value)
+}
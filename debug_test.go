@@ -0,0 +1,81 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func captureLog(fn func()) string {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	fn()
+	return buf.String()
+}
+
+func TestDebugCollision(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "tenant", 42)
+
+	out := captureLog(func() {
+		WithBaggage(ctx, "Tenant", "acme")
+	})
+	assert.Empty(t, out, "debug mode is off by default")
+
+	SetDebug(true)
+	defer SetDebug(false)
+	out = captureLog(func() {
+		ctx = WithBaggage(ctx, "Tenant", "acme")
+	})
+	assert.Contains(t, out, `writing baggage "tenant"`)
+	assert.Contains(t, out, "carries a int under the plain string key")
+
+	tenant, _ := Baggage(ctx, "tenant")
+	assert.Equal(t, "acme", tenant, "the write proceeds")
+
+	out = captureLog(func() {
+		WithBaggage(context.Background(), "Tenant", "acme")
+	})
+	assert.Empty(t, out)
+}
+
+func TestAdoptValue(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "Adopted-Tenant", "acme")
+	_, ok := Baggage(ctx, "Adopted-Tenant")
+	assert.False(t, ok)
+
+	ctx = AdoptValue(ctx, "Adopted-Tenant")
+	tenant, ok := Baggage(ctx, "Adopted-Tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+	assert.Contains(t, KnownKeys(), "adopted-tenant")
+
+	other := context.WithValue(context.Background(), "adopted-count", 42)
+	assert.Equal(t, other, AdoptValue(other, "adopted-count"), "only strings are adopted")
+	assert.Equal(t, other, AdoptValue(other, "adopted-missing"))
+}
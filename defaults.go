@@ -0,0 +1,60 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Default values are registered globally, typically at init time, and read
+// by any number of goroutines.
+var (
+	defaultsMu sync.RWMutex
+	defaults   map[baggageKey]string
+)
+
+// RegisterDefault declares a default value for a baggage key, which
+// BaggageWithDefaults returns when a context does not carry the key. Baggage
+// itself never consults defaults.
+func RegisterDefault(key, value string) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	if defaults == nil {
+		defaults = make(map[baggageKey]string)
+	}
+	defaults[baggageKey(canonicalKey(key))] = value
+}
+
+// BaggageWithDefaults returns the value for a given baggage key, or the
+// default registered for the key with RegisterDefault if the context does not
+// carry it.
+func BaggageWithDefaults(ctx context.Context, key string) (value string, ok bool) {
+	bkey := baggageKey(canonicalKey(key))
+	if value, ok := lookup(ctx, bkey); ok {
+		return value, true
+	}
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	value, ok = defaults[bkey]
+	return value, ok
+}
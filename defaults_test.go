@@ -0,0 +1,47 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestBaggageWithDefaults(t *testing.T) {
+	RegisterDefault("Priority", "normal")
+
+	ctx := context.Background()
+	priority, ok := BaggageWithDefaults(ctx, "priority")
+	assert.True(t, ok)
+	assert.Equal(t, "normal", priority)
+	_, ok = Baggage(ctx, "priority")
+	assert.False(t, ok, "plain Baggage ignores defaults")
+
+	ctx = WithBaggage(ctx, "Priority", "high")
+	priority, _ = BaggageWithDefaults(ctx, "priority")
+	assert.Equal(t, "high", priority)
+
+	_, ok = BaggageWithDefaults(ctx, "no-default")
+	assert.False(t, ok)
+}
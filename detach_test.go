@@ -0,0 +1,60 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestBaggageAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx = WithReceipt(ctx, "alice")
+	cancel()
+	<-ctx.Done()
+
+	assert.Equal(t, []string{"alice"}, Receipts(ctx))
+	assert.Equal(t, []string{"receipts"}, Keys(ctx))
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx = WithReceipt(ctx, "alice")
+	detached := Detach(ctx)
+	cancel()
+
+	_, ok := detached.Deadline()
+	assert.False(t, ok)
+	assert.Nil(t, detached.Done())
+	assert.NoError(t, detached.Err())
+	assert.Error(t, ctx.Err())
+
+	assert.Equal(t, []string{"alice"}, Receipts(detached))
+	assert.Equal(t, []string{"receipts"}, Keys(detached))
+
+	child, cancelChild := context.WithCancel(detached)
+	defer cancelChild()
+	assert.NoError(t, child.Err(), "children of a detached context are not canceled by its origin")
+}
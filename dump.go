@@ -0,0 +1,64 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"sort"
+
+	"golang.org/x/net/context"
+)
+
+// Dump returns the baggage carried by a context as one key=value line per
+// property, in sorted key order, for comparison against golden files. Values
+// are escaped with EncodeValue, so a value never spans lines.
+func Dump(ctx context.Context) string {
+	var buf bytes.Buffer
+	for _, key := range Keys(ctx) {
+		value, _ := Baggage(ctx, key)
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(EncodeValue(value))
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// An Entry is a baggage property, for presentation.
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// EntriesByValue returns the baggage carried by a context as entries sorted
+// by value, then by key, for presentation tooling such as a debug dashboard.
+func EntriesByValue(ctx context.Context) []Entry {
+	keys := Keys(ctx)
+	entries := make([]Entry, len(keys))
+	for i, key := range keys {
+		value, _ := Baggage(ctx, key)
+		entries[i] = Entry{key, value}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Value < entries[j].Value
+	})
+	return entries
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestDump(t *testing.T) {
+	assert.Equal(t, "", Dump(context.Background()))
+
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Note", "line one\nline two")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	ctx = WithBaggage(ctx, "Removed", "gone")
+	ctx = Remove(ctx, "Removed")
+
+	want := "note=line one%0Aline two\nregion=us-east\ntenant=acme\n"
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, want, Dump(ctx))
+	}
+}
+
+func TestEntriesByValue(t *testing.T) {
+	assert.Equal(t, []Entry{}, EntriesByValue(context.Background()))
+
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Zone", "b")
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Shard", "b")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	ctx = WithBaggage(ctx, "Backup", "b")
+
+	assert.Equal(t, []Entry{
+		{"tenant", "acme"},
+		{"backup", "b"},
+		{"shard", "b"},
+		{"zone", "b"},
+		{"region", "us-east"},
+	}, EntriesByValue(ctx))
+}
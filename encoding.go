@@ -0,0 +1,130 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"strings"
+)
+
+const upperhex = "0123456789ABCDEF"
+
+// Bytes that would break header formats that delimit baggage properties with
+// commas, semicolons, and equals signs, along with control characters and the
+// escape character itself, must be percent-encoded.
+func unsafeValueByte(c byte) bool {
+	switch c {
+	case ',', ';', '=', '%':
+		return true
+	}
+	return c < 0x20 || c == 0x7f
+}
+
+// EncodeValue percent-encodes the bytes in a baggage value that are unsafe in
+// header context: commas, semicolons, equals signs, percent signs, and control
+// characters. All other bytes pass through unchanged.
+func EncodeValue(s string) string {
+	return encodeBytes(s, unsafeValueByte)
+}
+
+// encodeBytes percent-encodes the bytes in a value that a format reports
+// unsafe, so that formats with different safe bytes share one escaping.
+func encodeBytes(s string, unsafe func(c byte) bool) string {
+	n := encodedLenBytes(s, unsafe)
+	if n == len(s) {
+		return s
+	}
+	buf := make([]byte, 0, n)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if unsafe(c) {
+			buf = append(buf, '%', upperhex[c>>4], upperhex[c&0xf])
+		} else {
+			buf = append(buf, c)
+		}
+	}
+	return string(buf)
+}
+
+// encodedLen returns the length of a value once encoded by EncodeValue.
+func encodedLen(s string) int {
+	return encodedLenBytes(s, unsafeValueByte)
+}
+
+func encodedLenBytes(s string, unsafe func(c byte) bool) int {
+	n := len(s)
+	for i := 0; i < len(s); i++ {
+		if unsafe(s[i]) {
+			n += 2
+		}
+	}
+	return n
+}
+
+// DecodeValue reverses EncodeValue, returning an error if the value contains
+// a malformed percent escape.
+func DecodeValue(s string) (string, error) {
+	if strings.IndexByte(s, '%') < 0 {
+		return s, nil
+	}
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '%' {
+			buf = append(buf, c)
+			continue
+		}
+		if i+2 >= len(s) || !ishex(s[i+1]) || !ishex(s[i+2]) {
+			escape := s[i:]
+			if len(escape) > 3 {
+				escape = escape[:3]
+			}
+			return "", fmt.Errorf("openctx: invalid percent escape %q", escape)
+		}
+		buf = append(buf, unhex(s[i+1])<<4|unhex(s[i+2]))
+		i += 2
+	}
+	return string(buf), nil
+}
+
+func ishex(c byte) bool {
+	switch {
+	case '0' <= c && c <= '9':
+		return true
+	case 'a' <= c && c <= 'f':
+		return true
+	case 'A' <= c && c <= 'F':
+		return true
+	}
+	return false
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeValue(t *testing.T) {
+	assert.Equal(t, "alice", EncodeValue("alice"))
+	assert.Equal(t, "a%2C b", EncodeValue("a, b"))
+	assert.Equal(t, "k%3Dv%3B q%3D0.5", EncodeValue("k=v; q=0.5"))
+	assert.Equal(t, "100%25", EncodeValue("100%"))
+	assert.Equal(t, "line%0Abreak%7F", EncodeValue("line\nbreak\x7f"))
+}
+
+func TestEncodeValueRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"alice",
+		",,,;;;===%%%",
+		"a=1,b=2;c=%41",
+		"tab\tnewline\r\nnull\x00",
+		"unicode ☃ stays",
+	}
+	for _, value := range values {
+		decoded, err := DecodeValue(EncodeValue(value))
+		assert.NoError(t, err)
+		assert.Equal(t, value, decoded)
+	}
+}
+
+func TestDecodeValueInvalidEscape(t *testing.T) {
+	for _, value := range []string{"%", "%4", "%zz", "a%2"} {
+		_, err := DecodeValue(value)
+		assert.Error(t, err, value)
+	}
+	decoded, err := DecodeValue("%2c")
+	assert.NoError(t, err)
+	assert.Equal(t, ",", decoded)
+}
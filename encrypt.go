@@ -0,0 +1,73 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+
+	"golang.org/x/net/context"
+)
+
+// Encrypted baggage values are a random nonce followed by the sealed value,
+// encoded as unpadded URL-safe base64 so that they travel as opaque,
+// header-safe strings. The key name is authenticated as additional data, so a
+// value cannot be replayed under another key.
+
+// nonceReader is the source of nonces for encrypted baggage.
+var nonceReader io.Reader = rand.Reader
+
+// WithEncryptedBaggage encrypts a value with an AEAD and adds the ciphertext
+// as the baggage value for a key. The key should not have a join function,
+// since joining would corrupt the ciphertext. It returns an error, and the
+// context unchanged, if a random nonce cannot be read.
+func WithEncryptedBaggage(ctx context.Context, key, value string, aead cipher.AEAD) (context.Context, error) {
+	key = canonicalKey(key)
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := io.ReadFull(nonceReader, nonce); err != nil {
+		return ctx, err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(key))
+	return WithBaggage(ctx, key, base64.RawURLEncoding.EncodeToString(sealed)), nil
+}
+
+// EncryptedBaggage decrypts the baggage value for a key added with
+// WithEncryptedBaggage. It returns false if the key is absent, or if the value
+// cannot be decrypted because it was tampered with or sealed by another key.
+func EncryptedBaggage(ctx context.Context, key string, aead cipher.AEAD) (string, bool) {
+	key = canonicalKey(key)
+	value, ok := Baggage(ctx, key)
+	if !ok {
+		return "", false
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", false
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(key))
+	if err != nil {
+		return "", false
+	}
+	return string(plain), true
+}
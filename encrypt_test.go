@@ -0,0 +1,98 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func testAEAD(t testing.TB, key string) cipher.AEAD {
+	block, err := aes.NewCipher([]byte(key))
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return aead
+}
+
+func TestEncryptedBaggage(t *testing.T) {
+	aead := testAEAD(t, "0123456789abcdef")
+	ctx, err := WithEncryptedBaggage(context.Background(), "User-ID", "user@example.com", aead)
+	require.NoError(t, err)
+
+	wire, ok := Baggage(ctx, "user-id")
+	assert.True(t, ok)
+	assert.NotContains(t, wire, "user@example.com")
+	assert.Equal(t, wire, EncodeValue(wire), "ciphertext is header safe")
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	out := DeserializeHeaders(context.Background(), h, "")
+	user, ok := EncryptedBaggage(out, "User-ID", aead)
+	assert.True(t, ok)
+	assert.Equal(t, "user@example.com", user)
+}
+
+func TestEncryptedBaggageTampered(t *testing.T) {
+	aead := testAEAD(t, "0123456789abcdef")
+	ctx, err := WithEncryptedBaggage(context.Background(), "User-ID", "user@example.com", aead)
+	require.NoError(t, err)
+	wire, _ := Baggage(ctx, "user-id")
+
+	tampered := []byte(wire)
+	i := len(tampered) / 2
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	_, ok := EncryptedBaggage(WithBaggage(ctx, "User-ID", string(tampered)), "User-ID", aead)
+	assert.False(t, ok)
+
+	_, ok = EncryptedBaggage(WithBaggage(ctx, "Other-ID", wire), "Other-ID", aead)
+	assert.False(t, ok, "the ciphertext is bound to its key")
+
+	_, ok = EncryptedBaggage(ctx, "User-ID", testAEAD(t, "fedcba9876543210"))
+	assert.False(t, ok)
+
+	_, ok = EncryptedBaggage(WithBaggage(ctx, "User-ID", "not base64!"), "User-ID", aead)
+	assert.False(t, ok)
+}
+
+func TestEncryptedBaggageNonceError(t *testing.T) {
+	defer func(r io.Reader) { nonceReader = r }(nonceReader)
+	nonceReader = iotest.ErrReader(errors.New("entropy exhausted"))
+
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	out, err := WithEncryptedBaggage(ctx, "User-ID", "user@example.com", testAEAD(t, "0123456789abcdef"))
+	assert.EqualError(t, err, "entropy exhausted")
+	assert.Equal(t, ctx, out)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// An EnumSpec is the set of values allowed for an enumerated baggage
+// property, like an environment that is one of "prod", "staging", or "dev".
+type EnumSpec struct {
+	allowed map[string]struct{}
+}
+
+// NewEnumSpec returns an EnumSpec allowing the given values.
+func NewEnumSpec(values ...string) EnumSpec {
+	allowed := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		allowed[value] = struct{}{}
+	}
+	return EnumSpec{allowed}
+}
+
+// Allows returns whether a value is one of the allowed values.
+func (s EnumSpec) Allows(value string) bool {
+	_, ok := s.allowed[value]
+	return ok
+}
+
+// WithEnum adds a baggage value like WithBaggage if it is allowed by the spec,
+// and otherwise returns the context unchanged and an error.
+func WithEnum(ctx context.Context, spec EnumSpec, key, value string) (context.Context, error) {
+	if !spec.Allows(value) {
+		return ctx, fmt.Errorf("openctx: %q is not an allowed value for baggage key %q", value, canonicalKey(key))
+	}
+	return WithBaggage(ctx, key, value), nil
+}
+
+// Enum returns the value for a baggage key if it is allowed by the spec. A
+// value received from a peer that the spec does not allow is treated as
+// absent.
+func Enum(ctx context.Context, spec EnumSpec, key string) (string, bool) {
+	value, ok := Baggage(ctx, key)
+	if !ok || !spec.Allows(value) {
+		return "", false
+	}
+	return value, true
+}
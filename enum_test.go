@@ -0,0 +1,54 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+var environments = NewEnumSpec("prod", "staging", "dev")
+
+func TestWithEnum(t *testing.T) {
+	ctx, err := WithEnum(context.Background(), environments, "Environment", "staging")
+	require.NoError(t, err)
+	env, ok := Enum(ctx, environments, "environment")
+	assert.True(t, ok)
+	assert.Equal(t, "staging", env)
+
+	out, err := WithEnum(ctx, environments, "Environment", "qa")
+	assert.EqualError(t, err, `openctx: "qa" is not an allowed value for baggage key "environment"`)
+	assert.Equal(t, ctx, out)
+	env, _ = Enum(out, environments, "Environment")
+	assert.Equal(t, "staging", env)
+}
+
+func TestEnumRejectsReceivedValue(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Environment", "Prod")
+	_, ok := Enum(ctx, environments, "Environment")
+	assert.False(t, ok)
+	_, ok = Enum(context.Background(), environments, "Environment")
+	assert.False(t, ok)
+}
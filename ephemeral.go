@@ -0,0 +1,53 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"golang.org/x/net/context"
+)
+
+// WithEphemeralBaggage adds a baggage value like WithBaggage, but without
+// learning the key globally, so the key does not join the set that every call
+// to Keys scans throughout the process, nor appear in KnownKeys. The key is
+// found instead through the keys set on the context itself. Baggage, Keys,
+// serializers, and Join all see an ephemeral key, and Join keeps it
+// ephemeral. Writing the key with WithBaggage learns it as usual.
+func WithEphemeralBaggage(ctx context.Context, key, value string) context.Context {
+	return withEphemeralBaggage(ctx, baggageKey(canonicalKey(key)), value)
+}
+
+func withEphemeralBaggage(ctx context.Context, bkey baggageKey, value string) context.Context {
+	if isSealed(ctx, bkey) {
+		return ctx
+	}
+	if join := joinFor(ctx, bkey); join != nil {
+		if prior, ok := lookup(ctx, bkey); ok {
+			value = callJoin(bkey, join, prior, value)
+			joined(bkey)
+			joins := joinCount(ctx, bkey) + 1
+			if removesKey(value) {
+				return &removedContext{ctx, map[baggageKey]struct{}{bkey: {}}, joins}
+			}
+			return storeJoined(ctx, bkey, value, joins)
+		}
+	}
+	return storeBaggage(ctx, bkey, value)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestWithEphemeralBaggage(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithEphemeralBaggage(ctx, "Ephemeral-Request-Nonce", "n1")
+
+	nonce, ok := Baggage(ctx, "Ephemeral-Request-Nonce")
+	assert.True(t, ok)
+	assert.Equal(t, "n1", nonce)
+	assert.Equal(t, []string{"ephemeral-request-nonce", "tenant"}, Keys(ctx))
+	assert.NotContains(t, KnownKeys(), "ephemeral-request-nonce")
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, "n1", h.Get("Ctx-Ephemeral-Request-Nonce"))
+	assert.NotContains(t, KnownKeys(), "ephemeral-request-nonce")
+}
+
+func TestWithEphemeralBaggageJoin(t *testing.T) {
+	ctx := WithEphemeralBaggage(context.Background(), "Ephemeral-Other", "a")
+	branch := WithEphemeralBaggage(ctx, "Ephemeral-Other", "b")
+	branch = WithEphemeralBaggage(branch, "Ephemeral-Only-Branch", "c")
+	ctx = Join(ctx, branch)
+
+	other, _ := Baggage(ctx, "Ephemeral-Other")
+	assert.Equal(t, "b", other)
+	only, _ := Baggage(ctx, "Ephemeral-Only-Branch")
+	assert.Equal(t, "c", only)
+	assert.NotContains(t, KnownKeys(), "ephemeral-only-branch")
+	assert.Equal(t, []string{"ephemeral-only-branch", "ephemeral-other"},
+		UnionKeys(context.Background(), ctx))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// now returns the current time. Tests replace it with a fake clock.
+var now = time.Now
+
+// A baggage value with an expiry is carried on the context map in place of
+// the plain string value.
+type expiringValue struct {
+	value  string
+	expiry time.Time
+}
+
+// WithBaggageExpiry adds a baggage value like WithBaggage that expires at the
+// given time. Once expired, the key is absent from Baggage, Keys, and every
+// serializer, and a later value for the key is not joined with it. The expiry
+// belongs to this process and is not serialized; a later write to the key
+// without an expiry replaces it.
+func WithBaggageExpiry(ctx context.Context, key, value string, expiry time.Time) context.Context {
+	bkey := baggageKey(canonicalKey(key))
+	if isSealed(ctx, bkey) {
+		return ctx
+	}
+	ctx = withBaggage(ctx, bkey, value)
+	value, _ = lookup(ctx, bkey)
+	return context.WithValue(ctx, bkey, expiringValue{value, expiry})
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+// useFakeClock replaces the clock with one that starts at a fixed time, and
+// returns functions that advance it and restore the real clock.
+func useFakeClock() (advance func(time.Duration), restore func()) {
+	current := time.Date(2016, 10, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	return func(d time.Duration) { current = current.Add(d) }, func() { now = time.Now }
+}
+
+func TestWithBaggageExpiry(t *testing.T) {
+	advance, restore := useFakeClock()
+	defer restore()
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggageExpiry(ctx, "Token", "t1", now().Add(time.Minute))
+
+	token, ok := Baggage(ctx, "Token")
+	assert.True(t, ok)
+	assert.Equal(t, "t1", token)
+	assert.Equal(t, []string{"tenant", "token"}, Keys(ctx))
+
+	advance(time.Minute)
+	_, ok = Baggage(ctx, "Token")
+	assert.False(t, ok)
+	assert.False(t, Has(ctx, "Token"))
+	assert.Equal(t, []string{"tenant"}, Keys(ctx))
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, http.Header{"Ctx-Tenant": {"acme"}}, h)
+}
+
+func TestWithBaggageExpiryJoin(t *testing.T) {
+	advance, restore := useFakeClock()
+	defer restore()
+	ctx := WithJoin(context.Background(), "Receipts", joinReceipts)
+	ctx = WithBaggageExpiry(ctx, "Receipts", "alice", now().Add(time.Second))
+	fresh := WithReceipt(ctx, "bob")
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(fresh))
+
+	advance(time.Second)
+	assert.Equal(t, []string{"bob"}, Receipts(WithReceipt(ctx, "bob")), "an expired value is not joined")
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(fresh), "a joined value has no expiry")
+}
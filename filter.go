@@ -0,0 +1,108 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// A Filter transforms the baggage on a context before propagation, for
+// example stripping, renaming, or dropping keys.
+type Filter func(ctx context.Context) context.Context
+
+// Chain composes filters into a single filter that applies them from left to
+// right.
+func Chain(filters ...Filter) Filter {
+	return func(ctx context.Context) context.Context {
+		for _, filter := range filters {
+			ctx = filter(ctx)
+		}
+		return ctx
+	}
+}
+
+// StripPrefix returns a filter that removes a prefix from every key that has
+// it, so that "internal-zone" becomes "zone" for the prefix "internal-".
+func StripPrefix(prefix string) Filter {
+	prefix = canonicalKey(prefix)
+	return func(ctx context.Context) context.Context {
+		var from, values []string
+		for _, key := range Keys(ctx) {
+			if len(key) > len(prefix) && strings.HasPrefix(key, prefix) {
+				value, _ := Baggage(ctx, key)
+				from = append(from, key)
+				values = append(values, value)
+			}
+		}
+		ctx = RemoveAll(ctx, from...)
+		for i, key := range from {
+			ctx = WithBaggage(ctx, key[len(prefix):], values[i])
+		}
+		return ctx
+	}
+}
+
+// RenameKey returns a filter that moves the value of one key to another.
+func RenameKey(from, to string) Filter {
+	return func(ctx context.Context) context.Context {
+		return rename(ctx, from, to)
+	}
+}
+
+// rename moves a baggage value to a new key, joining it with any value
+// already there.
+func rename(ctx context.Context, from, to string) context.Context {
+	value, ok := Baggage(ctx, from)
+	if !ok {
+		return ctx
+	}
+	ctx = Remove(ctx, from)
+	return WithBaggage(ctx, to, value)
+}
+
+// LimitKeys returns a filter that keeps at most n keys, removing the keys
+// that sort last.
+func LimitKeys(n int) Filter {
+	return func(ctx context.Context) context.Context {
+		keys := Keys(ctx)
+		if len(keys) <= n {
+			return ctx
+		}
+		return RemoveAll(ctx, keys[n:]...)
+	}
+}
+
+// KeepPrefix returns a filter that removes every key without a prefix, so
+// that only keys such as "app-tenant" remain for the prefix "app-".
+func KeepPrefix(prefix string) Filter {
+	prefix = canonicalKey(prefix)
+	return func(ctx context.Context) context.Context {
+		var drop []string
+		for _, key := range Keys(ctx) {
+			if !strings.HasPrefix(key, prefix) {
+				drop = append(drop, key)
+			}
+		}
+		return RemoveAll(ctx, drop...)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestRemove(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = WithBaggage(ctx, "Shard", "b")
+	removed := Remove(ctx, "Zone")
+	_, ok := Baggage(removed, "Zone")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"shard"}, Keys(removed))
+	assert.Equal(t, []string{"shard"}, KeysOrdered(removed))
+	assert.Equal(t, []string{"shard", "zone"}, Keys(ctx), "the origin is unchanged")
+
+	restored := WithBaggage(removed, "Zone", "c")
+	zone, _ := Baggage(restored, "Zone")
+	assert.Equal(t, "c", zone)
+
+	sealed := Remove(Seal(ctx, "Zone"), "Zone")
+	zone, _ = Baggage(sealed, "Zone")
+	assert.Equal(t, "a", zone)
+}
+
+func TestChainStripRename(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Internal-Zone", "a")
+	ctx = WithBaggage(ctx, "Internal-Tenant", "acme")
+	ctx = WithBaggage(ctx, "Receipts", "alice")
+
+	filter := Chain(
+		StripPrefix("internal-"),
+		RenameKey("tenant", "account"),
+	)
+	out := filter(ctx)
+	assert.Equal(t, []string{"account", "receipts", "zone"}, Keys(out))
+	account, _ := Baggage(out, "account")
+	assert.Equal(t, "acme", account)
+	zone, _ := Baggage(out, "zone")
+	assert.Equal(t, "a", zone)
+}
+
+func TestLimitKeys(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "c", "3")
+	ctx = WithBaggage(ctx, "a", "1")
+	ctx = WithBaggage(ctx, "b", "2")
+	assert.Equal(t, []string{"a", "b"}, Keys(LimitKeys(2)(ctx)))
+	assert.Equal(t, []string{"a", "b", "c"}, Keys(LimitKeys(5)(ctx)))
+
+	ctx = WithBaggage(ctx, "d", "4")
+	limited := LimitKeys(1)(ctx)
+	assert.Equal(t, []string{"a"}, Keys(limited))
+	removed, isNode := limited.(*removedContext)
+	assert.True(t, isNode, "all keys are removed by one context")
+	assert.Equal(t, ctx, removed.Context)
+}
+
+func TestKeepPrefix(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "App-Tenant", "acme")
+	ctx = WithBaggage(ctx, "App-Region", "us-east")
+	ctx = WithBaggage(ctx, "Token", "secret")
+	assert.Equal(t, []string{"app-region", "app-tenant"}, Keys(KeepPrefix("App-")(ctx)))
+	assert.Equal(t, []string{}, Keys(KeepPrefix("none-")(ctx)))
+}
+
+func TestRemoveAll(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Internal-Zone", "a")
+	ctx = WithBaggage(ctx, "Internal-Shard", "b")
+	ctx = WithBaggage(ctx, "Internal-Host", "c")
+	ctx = WithReceipt(ctx, "alice")
+
+	removed := RemoveAll(ctx, "Internal-Zone", "Internal-Shard", "Internal-Host", "Absent")
+	assert.Equal(t, []string{"receipts"}, Keys(removed))
+	assert.Equal(t, []string{"alice"}, Receipts(removed))
+	for _, key := range []string{"internal-zone", "internal-shard", "internal-host"} {
+		_, ok := Baggage(removed, key)
+		assert.False(t, ok, key)
+	}
+	_, isNode := removed.(*removedContext)
+	assert.True(t, isNode, "all keys are removed by one context")
+
+	assert.Equal(t, ctx, RemoveAll(ctx, "Absent"))
+	assert.Equal(t, ctx, RemoveAll(ctx))
+}
+
+func TestStripAll(t *testing.T) {
+	type requestValue struct{}
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx = context.WithValue(ctx, requestValue{}, "kept")
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+
+	stripped := StripAll(ctx)
+	assert.Equal(t, []string{}, Keys(stripped))
+	assert.False(t, HasBaggage(stripped))
+	got, ok := stripped.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, deadline, got)
+	assert.Equal(t, "kept", stripped.Value(requestValue{}))
+	assert.Equal(t, []string{"region", "tenant"}, Keys(ctx), "the original context is unchanged")
+
+	cancel()
+	assert.Equal(t, context.Canceled, stripped.Err())
+}
+
+func TestProject(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	ctx = WithBaggage(ctx, "Token", "secret")
+	ctx = WithBaggage(ctx, "Debug", "true")
+
+	projected := Project(ctx, "Tenant", "region", "Absent")
+	assert.Equal(t, []string{"region", "tenant"}, Keys(projected))
+	tenant, _ := Baggage(projected, "Tenant")
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, []string{"debug", "region", "tenant", "token"}, Keys(ctx), "the original context is unchanged")
+	assert.Equal(t, []string{}, Keys(Project(ctx)))
+
+	cancel()
+	assert.Equal(t, context.Canceled, projected.Err())
+}
+
+func TestProjectDropsSealedKeys(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Auth-Token", "secret")
+	ctx = Seal(ctx, "Tenant", "Auth-Token")
+
+	projected := Project(ctx, "Tenant")
+	assert.Equal(t, []string{"tenant"}, Keys(projected))
+	assert.False(t, Has(projected, "Auth-Token"))
+	assert.False(t, Sealed(projected, "Auth-Token"))
+	assert.True(t, Sealed(projected, "Tenant"))
+
+	h := http.Header{}
+	SerializeHeaders(projected, h, "")
+	assert.Empty(t, h.Get(DefaultHeaderPrefix+"Auth-Token"))
+}
+
+func TestProjectWritesNoTombstones(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Token", "secret")
+	ctx = WithHopLimit(ctx, "Region", "us-east", 2)
+
+	projected := Project(ctx, "Tenant", "Region")
+	assert.Equal(t, []string{}, TombstonedKeys(projected))
+	assert.Equal(t, []string{"tenant", "region", "openctx.hops.region"}, KeysOrdered(projected))
+	hops, ok := HopsRemaining(projected, "Region")
+	assert.True(t, ok)
+	assert.Equal(t, 2, hops)
+
+	h := http.Header{}
+	SerializeHeadersWithTombstones(projected, h, "")
+	for name, values := range h {
+		assert.NotEqual(t, []string{TombstoneMarker}, values, name)
+	}
+	assert.Empty(t, h.Get(DefaultHeaderPrefix+"Token"))
+}
+
+func TestTombstonedKeys(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, []string{}, TombstonedKeys(ctx))
+
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Token", "secret")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	ctx = RemoveAll(ctx, "Token", "Region")
+	assert.Equal(t, []string{"region", "token"}, TombstonedKeys(ctx))
+	assert.Equal(t, []string{"tenant"}, Keys(ctx))
+
+	ctx = WithBaggage(ctx, "Token", "renewed")
+	assert.Equal(t, []string{"region"}, TombstonedKeys(ctx))
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"golang.org/x/net/context"
+)
+
+// The name of a join function is carried on a context map by a join name key.
+// A join function introduced without a name shadows an earlier name with the
+// empty string.
+type joinNameKey string
+
+// RegisterJoin introduces a join function for a baggage property like
+// WithJoin, under a name that describes its semantics, such as "min" or
+// "union". Services that register the same names for the same keys produce
+// the same JoinFingerprint.
+func RegisterJoin(ctx context.Context, key, name string, join func(a, b string) string) context.Context {
+	ctx = WithJoin(ctx, key, join)
+	return context.WithValue(ctx, joinNameKey(canonicalKey(key)), name)
+}
+
+// JoinFingerprint returns a stable identifier for the join functions in
+// context, derived from each key that has a join function and the name it was
+// registered under with RegisterJoin. Join functions introduced without a
+// name contribute only their key. Tooling can compare fingerprints between
+// services to detect keys that merge differently.
+func JoinFingerprint(ctx context.Context) string {
+	var entries []string
+	for _, bkey := range learnedKeys() {
+		if ctx.Value(joinKey(bkey)) == nil {
+			continue
+		}
+		name, _ := ctx.Value(joinNameKey(bkey)).(string)
+		entries = append(entries, string(bkey)+"="+name)
+	}
+	sort.Strings(entries)
+	h := fnv.New64a()
+	for _, entry := range entries {
+		h.Write([]byte(entry))
+		h.Write([]byte{'\n'})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
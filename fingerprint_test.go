@@ -0,0 +1,51 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestJoinFingerprint(t *testing.T) {
+	a := RegisterJoin(context.Background(), "TTL", "min", joinTTL)
+	b := RegisterJoin(context.Background(), "TTL", "max", joinTTL)
+	assert.NotEqual(t, JoinFingerprint(a), JoinFingerprint(b))
+
+	c := RegisterJoin(context.Background(), "ttl", "min", joinTTL)
+	assert.Equal(t, JoinFingerprint(a), JoinFingerprint(c))
+	assert.Equal(t, JoinFingerprint(a), JoinFingerprint(WithBaggage(a, "TTL", "10")))
+}
+
+func TestJoinFingerprintUnnamed(t *testing.T) {
+	named := RegisterJoin(context.Background(), "TTL", "min", joinTTL)
+	unnamed := WithJoin(context.Background(), "TTL", joinTTL)
+	assert.NotEqual(t, JoinFingerprint(named), JoinFingerprint(unnamed))
+	assert.NotEqual(t, JoinFingerprint(context.Background()), JoinFingerprint(unnamed))
+
+	replaced := WithJoin(named, "TTL", joinTTL)
+	assert.Equal(t, JoinFingerprint(unnamed), JoinFingerprint(replaced))
+	replaced = WithJoinMap(named, map[string]func(a, b string) string{"TTL": joinTTL})
+	assert.Equal(t, JoinFingerprint(unnamed), JoinFingerprint(replaced))
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// SerializeFlag returns the baggage carried by a context as a single token
+// for a command line flag, such as "--ctx=<token>", for a process that
+// re-invokes itself. The token is the string form of the baggage from String,
+// encoded in unpadded URL safe base64, so that it needs no shell quoting.
+func SerializeFlag(ctx context.Context) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(String(ctx)))
+}
+
+// ParseFlag returns a context carrying the baggage from a token produced by
+// SerializeFlag, joining each value with any prior value through the join
+// functions in context. It returns an error for a malformed token, in which
+// case no baggage is added.
+func ParseFlag(ctx context.Context, token string) (context.Context, error) {
+	s, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ctx, fmt.Errorf("openctx: malformed baggage flag: %v", err)
+	}
+	return Parse(ctx, string(s))
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func TestFlagRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme; \"corp\"")
+	ctx = WithBaggage(ctx, "Region", "us-east=1")
+
+	token := SerializeFlag(ctx)
+	assert.NotContains(t, token, "=")
+	assert.NotContains(t, token, " ")
+
+	parsed, err := ParseFlag(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, Dump(ctx), Dump(parsed))
+
+	parsed, err = ParseFlag(context.Background(), SerializeFlag(context.Background()))
+	require.NoError(t, err)
+	assert.False(t, HasBaggage(parsed))
+}
+
+func TestParseFlagMalformed(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	for _, token := range []string{
+		"not base64!",
+		"YWJj=",
+		base64.RawURLEncoding.EncodeToString([]byte("tenant")),
+		base64.RawURLEncoding.EncodeToString([]byte("tenant=%zz")),
+	} {
+		parsed, err := ParseFlag(ctx, token)
+		assert.Error(t, err, token)
+		assert.Equal(t, ctx, parsed, token)
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.18
+// +build go1.18
+
+package openctx
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// Each fuzz target feeds arbitrary input to a deserializer, which must not
+// panic. Where the input decodes, encoding the result and decoding it again
+// must reproduce the same encoding, since the first encoding is canonical.
+// Input carrying hop limits is not re-encoded, since every encoding counts
+// them down.
+
+func hasHopLimits(ctx context.Context) bool {
+	for _, key := range Keys(ctx) {
+		if strings.HasPrefix(key, hopKeyPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 0})
+	seed, _ := Marshal(WithBaggage(context.Background(), "tenant", "acme"))
+	f.Add(seed)
+	f.Add([]byte{1, 1, 0xff, 0xff, 0xff, 0xff, 0x0f})
+	f.Add([]byte{2, 0})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ctx, err := Unmarshal(context.Background(), b)
+		if err != nil || hasHopLimits(ctx) {
+			return
+		}
+		encoded, err := Marshal(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := Unmarshal(context.Background(), encoded)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", encoded, err)
+		}
+		if reencoded, _ := Marshal(again); !bytes.Equal(encoded, reencoded) {
+			t.Fatalf("%q re-encoded as %q", encoded, reencoded)
+		}
+	})
+}
+
+func FuzzUnmarshalCompressed(f *testing.F) {
+	plain, _ := MarshalCompressed(WithBaggage(context.Background(), "tenant", "acme"))
+	f.Add(plain)
+	f.Add([]byte{formatGzip, 0x1f, 0x8b})
+	f.Add([]byte{7})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ctx, err := UnmarshalCompressed(context.Background(), b)
+		if err != nil {
+			return
+		}
+		encoded, err := MarshalCompressed(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := UnmarshalCompressed(context.Background(), encoded); err != nil {
+			t.Fatalf("decoding %q: %v", encoded, err)
+		}
+	})
+}
+
+func FuzzDecodeValue(f *testing.F) {
+	f.Add("acme")
+	f.Add("a%2C b")
+	f.Add("%")
+	f.Add("%zz")
+	f.Fuzz(func(t *testing.T, s string) {
+		value, err := DecodeValue(s)
+		if err != nil {
+			return
+		}
+		encoded := EncodeValue(value)
+		again, err := DecodeValue(encoded)
+		if err != nil || again != value {
+			t.Fatalf("%q decoded as %q, then %q as %q, %v", s, value, encoded, again, err)
+		}
+	})
+}
+
+func FuzzUnmarshalW3CBaggage(f *testing.F) {
+	f.Add("tenant=acme,region=us-east")
+	f.Add("tenant=acme;prop=1, note=a%20b")
+	f.Add("=,;=")
+	f.Fuzz(func(t *testing.T, s string) {
+		ctx, err := UnmarshalW3CBaggage(context.Background(), s)
+		if err != nil {
+			return
+		}
+		if hasHopLimits(ctx) {
+			return
+		}
+		encoded := MarshalW3CBaggage(ctx)
+		again, err := UnmarshalW3CBaggage(context.Background(), encoded)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", encoded, err)
+		}
+		if reencoded := MarshalW3CBaggage(again); encoded != reencoded {
+			t.Fatalf("%q re-encoded as %q", encoded, reencoded)
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("region=us-east;tenant=acme")
+	f.Add("note=a%3Bb")
+	f.Add(";=")
+	f.Fuzz(func(t *testing.T, s string) {
+		ctx, err := Parse(context.Background(), s)
+		if err != nil || hasHopLimits(ctx) {
+			return
+		}
+		encoded := String(ctx)
+		again, err := Parse(context.Background(), encoded)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", encoded, err)
+		}
+		if reencoded := String(again); encoded != reencoded {
+			t.Fatalf("%q re-encoded as %q", encoded, reencoded)
+		}
+	})
+}
+
+func FuzzParseSQLComment(f *testing.F) {
+	f.Add("SELECT 1 /*tenant='acme',note='a%20b'*/")
+	f.Add("/**/")
+	f.Add("/*/")
+	f.Add("*/")
+	f.Fuzz(func(t *testing.T, query string) {
+		ctx := ParseSQLComment(context.Background(), query)
+		if hasHopLimits(ctx) {
+			return
+		}
+		encoded := ToSQLComment(ctx)
+		if reencoded := ToSQLComment(ParseSQLComment(context.Background(), encoded)); encoded != reencoded {
+			t.Fatalf("%q re-encoded as %q", encoded, reencoded)
+		}
+	})
+}
+
+func FuzzDeserializeHeaders(f *testing.F) {
+	f.Add("Ctx-Tenant", "acme")
+	f.Add("ctx-", "%")
+	f.Add("Ctx-Note", TombstoneMarker)
+	f.Fuzz(func(t *testing.T, name, value string) {
+		ctx := DeserializeHeaders(context.Background(), http.Header{name: {value}}, "")
+		if hasHopLimits(ctx) {
+			return
+		}
+		h := http.Header{}
+		SerializeHeaders(ctx, h, "")
+		again := http.Header{}
+		SerializeHeaders(DeserializeHeaders(context.Background(), h, ""), again, "")
+		if len(h) != len(again) {
+			t.Fatalf("%v re-encoded as %v", h, again)
+		}
+		for name := range h {
+			if h.Get(name) != again.Get(name) {
+				t.Fatalf("%v re-encoded as %v", h, again)
+			}
+		}
+	})
+}
+
+func FuzzJSONPropagatorExtract(f *testing.F) {
+	f.Add([]byte(`{"tenant": "acme", "note": "a, b"}`))
+	f.Add([]byte(`{"receipts": 1}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`{"":""}`))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ctx, err := JSONPropagator{}.Extract(context.Background(), b)
+		if err != nil || hasHopLimits(ctx) {
+			return
+		}
+		var encoded bytes.Buffer
+		if err := (JSONPropagator{}).Inject(ctx, &encoded); err != nil {
+			t.Fatal(err)
+		}
+		again, err := JSONPropagator{}.Extract(context.Background(), encoded.Bytes())
+		if err != nil && err != ErrNoBaggage {
+			t.Fatalf("decoding %q: %v", encoded.Bytes(), err)
+		}
+		var reencoded bytes.Buffer
+		if err := (JSONPropagator{}).Inject(again, &reencoded); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded.Bytes(), reencoded.Bytes()) {
+			t.Fatalf("%q re-encoded as %q", encoded.Bytes(), reencoded.Bytes())
+		}
+	})
+}
+
+func FuzzEncryptedBaggage(f *testing.F) {
+	f.Add("user@example.com", "")
+	f.Add("", "AAAAAAAAAAAAAAAA")
+	f.Add("x", "not base64!")
+	aead := testAEAD(f, "0123456789abcdef")
+	f.Fuzz(func(t *testing.T, plain, wire string) {
+		ctx := WithBaggage(context.Background(), "User-ID", wire)
+		if _, ok := EncryptedBaggage(ctx, "User-ID", aead); ok {
+			t.Fatalf("%q decrypted without being encrypted", wire)
+		}
+		ctx, err := WithEncryptedBaggage(context.Background(), "User-ID", plain, aead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := EncryptedBaggage(ctx, "User-ID", aead); !ok || got != plain {
+			t.Fatalf("%q decrypted as %q, %v", plain, got, ok)
+		}
+	})
+}
+
+func FuzzExtractText(f *testing.F) {
+	f.Add("ctx-tenant", "acme")
+	f.Add("CTX-", "%")
+	f.Add("ctx-note", TombstoneMarker)
+	f.Fuzz(func(t *testing.T, key, value string) {
+		ctx := ExtractText(context.Background(), MapCarrier{key: value})
+		if hasHopLimits(ctx) {
+			return
+		}
+		encoded := MapCarrier{}
+		InjectText(ctx, encoded)
+		reencoded := MapCarrier{}
+		InjectText(ExtractText(context.Background(), encoded), reencoded)
+		if len(encoded) != len(reencoded) {
+			t.Fatalf("%v re-encoded as %v", encoded, reencoded)
+		}
+		for key, value := range encoded {
+			if reencoded[key] != value {
+				t.Fatalf("%v re-encoded as %v", encoded, reencoded)
+			}
+		}
+	})
+}
+
+func FuzzDeserializeVerify(f *testing.F) {
+	h := http.Header{}
+	SerializeWithChecksum(WithBaggage(context.Background(), "tenant", "acme"), h)
+	f.Add("Ctx-Tenant", "acme", h.Get("Ctx-Checksum"))
+	f.Add("Ctx-Tenant", "acme", "")
+	f.Add("Other", "x", "0")
+	f.Fuzz(func(t *testing.T, name, value, checksum string) {
+		ctx, err := DeserializeVerify(context.Background(), http.Header{
+			name:           {value},
+			"Ctx-Checksum": {checksum},
+		})
+		if err != nil || hasHopLimits(ctx) {
+			return
+		}
+		h := http.Header{}
+		SerializeWithChecksum(ctx, h)
+		if _, err := DeserializeVerify(context.Background(), h); err != nil {
+			t.Fatalf("verifying %v: %v", h, err)
+		}
+	})
+}
+
+func FuzzParseFlag(f *testing.F) {
+	f.Add(SerializeFlag(WithBaggage(context.Background(), "tenant", "acme")))
+	f.Add("")
+	f.Add("!!")
+	f.Fuzz(func(t *testing.T, token string) {
+		ctx, err := ParseFlag(context.Background(), token)
+		if err != nil || hasHopLimits(ctx) {
+			return
+		}
+		encoded := SerializeFlag(ctx)
+		again, err := ParseFlag(context.Background(), encoded)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", encoded, err)
+		}
+		if reencoded := SerializeFlag(again); encoded != reencoded {
+			t.Fatalf("%q re-encoded as %q", encoded, reencoded)
+		}
+	})
+}
+
+func FuzzDeserializeCombined(f *testing.F) {
+	f.Add("tenant=acme,region=us-east")
+	f.Add("a=1;p, ,b=%25")
+	f.Add("=")
+	f.Fuzz(func(t *testing.T, value string) {
+		ctx, err := DeserializeCombined(context.Background(), http.Header{W3CBaggageHeader: {value}}, W3CBaggageHeader)
+		if err != nil || hasHopLimits(ctx) {
+			return
+		}
+		h := http.Header{}
+		SerializeCombined(ctx, h, W3CBaggageHeader)
+		again, err := DeserializeCombined(context.Background(), h, W3CBaggageHeader)
+		if err != nil {
+			t.Fatalf("decoding %v: %v", h, err)
+		}
+		reencoded := http.Header{}
+		SerializeCombined(again, reencoded, W3CBaggageHeader)
+		if h.Get(W3CBaggageHeader) != reencoded.Get(W3CBaggageHeader) {
+			t.Fatalf("%v re-encoded as %v", h, reencoded)
+		}
+	})
+}
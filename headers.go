@@ -0,0 +1,305 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultHeaderPrefix distinguishes baggage headers from other HTTP headers
+// when no other prefix is configured.
+const DefaultHeaderPrefix = "ctx-"
+
+// TombstoneMarker is the header value that signals the deletion of a baggage
+// key. It is not a valid escaped value, so no baggage value encodes to it.
+const TombstoneMarker = "%-deleted"
+
+// A header prefix configured for a service is carried on the context by this
+// key.
+type headerPrefixKey struct{}
+
+// WithHeaderPrefix returns a context that configures the header prefix for
+// SerializeHeaders and DeserializeHeaders when they are not given one
+// explicitly. An empty prefix would make every header baggage, so it selects
+// DefaultHeaderPrefix instead.
+func WithHeaderPrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, headerPrefixKey{}, prefix)
+}
+
+// headerPrefix resolves the prefix for baggage headers, preferring an explicit
+// prefix, then a prefix configured on the context, then the default.
+func headerPrefix(ctx context.Context, prefix string) string {
+	if prefix != "" {
+		return prefix
+	}
+	if prefix, _ := ctx.Value(headerPrefixKey{}).(string); prefix != "" {
+		return prefix
+	}
+	return DefaultHeaderPrefix
+}
+
+// An egress allow-list is carried on the context by this key, as a set of
+// baggage keys.
+type egressAllowKey struct{}
+
+// WithEgressAllow returns a context that restricts header serialization to
+// the given keys, replacing any prior allow-list. Without an allow-list, all
+// keys are serialized.
+func WithEgressAllow(ctx context.Context, keys ...string) context.Context {
+	allow := make(map[baggageKey]struct{}, len(keys))
+	for _, key := range keys {
+		allow[baggageKey(canonicalKey(key))] = struct{}{}
+	}
+	return context.WithValue(ctx, egressAllowKey{}, allow)
+}
+
+// Empty values are dropped from egress when this is on.
+var dropEmpty atomicFlag
+
+// DropEmptyValues turns dropping empty values from egress on or off. With it
+// on, keys whose value is the empty string are left out of header, text map,
+// W3C and SQL comment serialization, saving the space that they would waste.
+// It is off by default.
+func DropEmptyValues(on bool) {
+	dropEmpty.set(on)
+}
+
+// egressKeys returns the sorted baggage keys on a context that its egress
+// allow-list permits to be serialized. A hop count is permitted along with the
+// key it limits.
+func egressKeys(ctx context.Context) []string {
+	keys := Keys(ctx)
+	allow, ok := ctx.Value(egressAllowKey{}).(map[baggageKey]struct{})
+	drop := dropEmpty.on()
+	if !ok && !drop {
+		return keys
+	}
+	allowed := keys[:0]
+	for _, key := range keys {
+		if _, ok := allow[baggageKey(strings.TrimPrefix(key, hopKeyPrefix))]; allow != nil && !ok {
+			continue
+		}
+		if drop {
+			if value, _ := Baggage(ctx, key); value == "" {
+				continue
+			}
+		}
+		allowed = append(allowed, key)
+	}
+	return allowed
+}
+
+// egressEntries returns the baggage properties that egressKeys permits, in
+// sorted key order, with the values to write on the wire.
+func egressEntries(ctx context.Context) []Entry {
+	keys := egressKeys(ctx)
+	entries := make([]Entry, len(keys))
+	for i, key := range keys {
+		value, _ := Baggage(ctx, key)
+		entries[i] = Entry{key, outboundValue(key, value)}
+	}
+	return entries
+}
+
+// OutboundEntries returns the baggage carried by a context as it should be
+// written onto the wire, in sorted key order, for a codec written outside this
+// package. Like the codecs of this package, it leaves out keys that the
+// egress allow-list or DropEmptyValues exclude, and counts down hop limits.
+// The codec should call DropExhausted after reading baggage back.
+func OutboundEntries(ctx context.Context) []Entry {
+	return egressEntries(ctx)
+}
+
+// SerializeHeaders writes the baggage carried by a context onto HTTP headers,
+// one header per property, named by the prefix and the baggage key. Values are
+// escaped with EncodeValue. If prefix is empty, the prefix configured with
+// WithHeaderPrefix applies, or DefaultHeaderPrefix. If the context carries an
+// egress allow-list from WithEgressAllow, only allowed keys are written.
+func SerializeHeaders(ctx context.Context, h http.Header, prefix string) {
+	injectText(ctx, HTTPHeaderCarrier(h), headerPrefix(ctx, prefix))
+}
+
+// SerializedSize returns the number of bytes that SerializeHeaders would add
+// to an HTTP/1.1 request or response: for each property, a header line of the
+// prefix and key, a colon and space, the escaped value, and a line break.
+func SerializedSize(ctx context.Context, prefix string) int {
+	prefix = headerPrefix(ctx, prefix)
+	size := 0
+	for _, key := range egressKeys(ctx) {
+		value, _ := Baggage(ctx, key)
+		size += headerSize(prefix, key, outboundValue(key, value))
+	}
+	return size
+}
+
+// headerSize returns the size of the header line for a baggage property.
+func headerSize(prefix, key, value string) int {
+	return len(prefix) + len(key) + len(": ") + encodedLen(value) + len("\r\n")
+}
+
+// SerializeHeadersWithTombstones writes baggage onto HTTP headers like
+// SerializeHeaders, and also writes a header with the value TombstoneMarker
+// for each key that was removed from the context, instructing the receiver to
+// drop the key from its own context.
+func SerializeHeadersWithTombstones(ctx context.Context, h http.Header, prefix string) {
+	SerializeHeaders(ctx, h, prefix)
+	prefix = headerPrefix(ctx, prefix)
+	allow, _ := ctx.Value(egressAllowKey{}).(map[baggageKey]struct{})
+	for _, bkey := range tombstonedKeys(ctx) {
+		if _, ok := allow[bkey]; allow != nil && !ok {
+			continue
+		}
+		h.Set(prefix+string(bkey), TombstoneMarker)
+	}
+}
+
+// DeserializeHeaders returns a context carrying the baggage from HTTP headers
+// with the given prefix, joining each value with any prior value through the
+// join functions in context. Header names match the prefix without regard to
+// case. Headers with malformed escapes are skipped. If prefix is empty, the
+// prefix configured with WithHeaderPrefix applies, or DefaultHeaderPrefix.
+//
+// A header may appear more than once. If the key has a join function in
+// context, every value is joined in order, so repeated receipts headers
+// produce the union of receipts. Otherwise only the first value is taken.
+//
+// A header with the value TombstoneMarker removes the key from the context.
+func DeserializeHeaders(ctx context.Context, h http.Header, prefix string) context.Context {
+	prefix = headerPrefix(ctx, prefix)
+	var keys []string
+	for name, values := range h {
+		key, ok := headerKey(name, prefix)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		keys = append(keys, key)
+		if joinFor(ctx, baggageKey(key)) == nil {
+			values = values[:1]
+		}
+		for _, value := range values {
+			if value == TombstoneMarker {
+				ctx = Remove(ctx, key)
+				continue
+			}
+			value, err := DecodeValue(value)
+			if err != nil {
+				continue
+			}
+			ctx = WithBaggage(ctx, key, value)
+		}
+	}
+	return dropExhausted(ctx, keys)
+}
+
+// headerKey returns the canonical baggage key for a header name, if the name
+// has the baggage prefix.
+func headerKey(name, prefix string) (string, bool) {
+	if len(name) <= len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+		return "", false
+	}
+	return canonicalKey(name[len(prefix):]), true
+}
+
+// DeserializeHeadersAllow is like DeserializeHeaders with the configured or
+// default prefix, but accepts only the given baggage keys, along with their
+// hop counts. If the headers carry any other baggage key, it returns the
+// context unchanged and an error naming the keys that are not allowed.
+func DeserializeHeadersAllow(ctx context.Context, h http.Header, allowed ...string) (context.Context, error) {
+	allow := make(map[string]struct{}, len(allowed))
+	for _, key := range allowed {
+		allow[canonicalKey(key)] = struct{}{}
+	}
+	prefix := headerPrefix(ctx, "")
+	var disallowed []string
+	for name := range h {
+		key, ok := headerKey(name, prefix)
+		if !ok {
+			continue
+		}
+		if _, ok := allow[strings.TrimPrefix(key, hopKeyPrefix)]; !ok {
+			disallowed = append(disallowed, key)
+		}
+	}
+	if len(disallowed) > 0 {
+		sort.Strings(disallowed)
+		return ctx, fmt.Errorf("openctx: baggage keys not allowed: %s", strings.Join(disallowed, ", "))
+	}
+	return DeserializeHeaders(ctx, h, prefix), nil
+}
+
+// DeserializeAndJoin merges the baggage from several sets of HTTP headers,
+// like the responses to parallel requests, into a context, joining values
+// through the join functions in context. It is the counterpart of JoinAll for
+// baggage on the wire. Headers are read with the prefix configured by
+// WithHeaderPrefix, or DefaultHeaderPrefix.
+func DeserializeAndJoin(ctx context.Context, headers ...http.Header) context.Context {
+	for _, h := range headers {
+		ctx = DeserializeHeaders(ctx, h, "")
+	}
+	return ctx
+}
+
+// InjectRequest writes the baggage carried by the context of an HTTP request
+// onto its headers with SerializeHeaders, after applying any filters to the
+// baggage. The request's own context is not changed. If prefix is empty, the
+// prefix configured with WithHeaderPrefix applies, or DefaultHeaderPrefix.
+func InjectRequest(req *http.Request, prefix string, filters ...Filter) {
+	ctx := Chain(filters...)(req.Context())
+	SerializeHeaders(ctx, req.Header, prefix)
+}
+
+// ExtractRequest returns a shallow copy of an HTTP request whose context
+// carries the baggage from the request's headers, read with DeserializeHeaders
+// and the configured or default prefix. The join functions and header prefix
+// of the request's existing context apply, such as those a server installs
+// on its base context.
+func ExtractRequest(req *http.Request) *http.Request {
+	return req.WithContext(DeserializeHeaders(req.Context(), req.Header, ""))
+}
+
+// SerializeCombined writes the baggage carried by a context onto a single HTTP
+// header in the W3C baggage format, as by MarshalW3CBaggage, for peers or
+// proxies that handle one header better than one per property. Nothing is
+// written if there is no baggage. If the context carries an egress allow-list
+// from WithEgressAllow, only allowed keys are written.
+func SerializeCombined(ctx context.Context, h http.Header, headerName string) {
+	if s := MarshalW3CBaggage(ctx); s != "" {
+		h.Set(headerName, s)
+	}
+}
+
+// DeserializeCombined returns a context carrying the baggage from a single
+// HTTP header written by SerializeCombined, joining each value with any prior
+// value through the join functions in context. If the header appears more than
+// once, its values are read as one comma-separated list. It returns an error
+// for a malformed member, in which case no baggage is added.
+func DeserializeCombined(ctx context.Context, h http.Header, headerName string) (context.Context, error) {
+	values := h[http.CanonicalHeaderKey(headerName)]
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	return UnmarshalW3CBaggage(ctx, strings.Join(values, ","))
+}
@@ -0,0 +1,364 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestSerializeHeadersDefaultPrefix(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithReceipt(ctx, "bob")
+	ctx = WithTTL(ctx, time.Second)
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, http.Header{
+		"Ctx-Receipts": {"alice%2C bob"},
+		"Ctx-Ttl":      {"1000"},
+	}, h)
+
+	out := DeserializeHeaders(context.Background(), h, "")
+	assert.Equal(t, []string{"receipts", "ttl"}, Keys(out))
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(out))
+}
+
+func TestSerializeHeadersConfiguredPrefix(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithHeaderPrefix(ctx, "x-baggage-")
+	ctx = WithReceipt(ctx, "alice")
+
+	h := http.Header{}
+	h.Set("Ctx-Stale", "ignored")
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, "alice", h.Get("X-Baggage-Receipts"))
+
+	out := DeserializeHeaders(WithHeaderPrefix(context.Background(), "x-baggage-"), h, "")
+	assert.Equal(t, []string{"receipts"}, Keys(out))
+	assert.Equal(t, []string{"alice"}, Receipts(out))
+}
+
+func TestEmptyHeaderPrefixUsesDefault(t *testing.T) {
+	ctx := WithHeaderPrefix(context.Background(), "x-baggage-")
+	ctx = WithHeaderPrefix(ctx, "")
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, http.Header{"Ctx-Tenant": {"acme"}}, h)
+
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	assert.NoError(t, err)
+	req = req.WithContext(WithHeaderPrefix(context.Background(), ""))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=1")
+	req.Header.Set("Ctx-Tenant", "acme")
+	out := ExtractRequest(req)
+	assert.Equal(t, []string{"tenant"}, Keys(out.Context()))
+}
+
+func TestSerializeHeadersExplicitPrefix(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithHeaderPrefix(ctx, "x-baggage-")
+	ctx = WithReceipt(ctx, "alice")
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "uberctx-")
+	assert.Equal(t, http.Header{"Uberctx-Receipts": {"alice"}}, h)
+}
+
+func TestDeserializeHeadersJoins(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+
+	h := http.Header{}
+	h.Set("Ctx-Receipts", "bob%2C charlie")
+	h.Set("Ctx-Broken", "%zz")
+	h.Set("Content-Type", "text/plain")
+	ctx = DeserializeHeaders(ctx, h, "")
+	assert.Equal(t, []string{"alice", "bob", "charlie"}, Receipts(ctx))
+	assert.Equal(t, []string{"receipts"}, Keys(ctx))
+}
+
+func TestDeserializeHeadersRepeated(t *testing.T) {
+	h := http.Header{}
+	h.Add("Ctx-Receipts", "alice%2C bob")
+	h.Add("Ctx-Receipts", "charlie")
+	h.Add("Ctx-Receipts", "bob")
+	h.Add("Ctx-Shard", "a")
+	h.Add("Ctx-Shard", "b")
+
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = DeserializeHeaders(ctx, h, "")
+	assert.Equal(t, []string{"alice", "bob", "charlie"}, Receipts(ctx))
+	shard, _ := Baggage(ctx, "shard")
+	assert.Equal(t, "a", shard, "keys without a join take the first value")
+}
+
+func TestSerializeHeadersOmitsTombstones(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = WithBaggage(ctx, "Shard", "b")
+	ctx = Remove(ctx, "Zone")
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, http.Header{"Ctx-Shard": {"b"}}, h)
+}
+
+func TestSerializeHeadersWithTombstones(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = WithBaggage(ctx, "Shard", "b")
+	ctx = Remove(ctx, "Zone")
+
+	h := http.Header{}
+	SerializeHeadersWithTombstones(ctx, h, "")
+	assert.Equal(t, http.Header{
+		"Ctx-Shard": {"b"},
+		"Ctx-Zone":  {TombstoneMarker},
+	}, h)
+
+	downstream := WithBaggage(context.Background(), "Zone", "stale")
+	downstream = DeserializeHeaders(downstream, h, "")
+	_, ok := Baggage(downstream, "Zone")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"shard"}, Keys(downstream))
+}
+
+func TestTombstoneMarkerIsNotAValue(t *testing.T) {
+	_, err := DecodeValue(TombstoneMarker)
+	assert.Error(t, err)
+	assert.NotEqual(t, TombstoneMarker, EncodeValue(TombstoneMarker))
+}
+
+func TestSerializedSize(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, 0, SerializedSize(ctx, ""))
+
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithReceipt(ctx, "bob")
+	ctx = WithBaggage(ctx, "Filter", "a=1;b=2,c=%")
+	ctx = WithTTL(ctx, time.Second)
+
+	for _, prefix := range []string{"", "uberctx-"} {
+		h := http.Header{}
+		SerializeHeaders(ctx, h, prefix)
+		var buf bytes.Buffer
+		assert.NoError(t, h.Write(&buf))
+		assert.Equal(t, buf.Len(), SerializedSize(ctx, prefix), "prefix %q", prefix)
+	}
+}
+
+func TestSerializeHeadersEgressAllow(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBaggage(ctx, "Internal-Host", "db-3")
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = Remove(ctx, "Zone")
+
+	h := http.Header{}
+	SerializeHeadersWithTombstones(ctx, h, "")
+	assert.Equal(t, http.Header{
+		"Ctx-Receipts":      {"alice"},
+		"Ctx-Internal-Host": {"db-3"},
+		"Ctx-Zone":          {TombstoneMarker},
+	}, h)
+
+	ctx = WithEgressAllow(ctx, "Receipts", "Absent")
+	h = http.Header{}
+	SerializeHeadersWithTombstones(ctx, h, "")
+	assert.Equal(t, http.Header{"Ctx-Receipts": {"alice"}}, h)
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.Write(&buf))
+	assert.Equal(t, buf.Len(), SerializedSize(ctx, ""))
+}
+
+func TestDropEmptyValues(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Debug", "")
+	ctx = WithBaggage(ctx, "Zone", "")
+
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, http.Header{
+		"Ctx-Tenant": {"acme"},
+		"Ctx-Debug":  {""},
+		"Ctx-Zone":   {""},
+	}, h, "empty values are kept by default")
+
+	DropEmptyValues(true)
+	defer DropEmptyValues(false)
+
+	h = http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, http.Header{"Ctx-Tenant": {"acme"}}, h)
+	var buf bytes.Buffer
+	assert.NoError(t, h.Write(&buf))
+	assert.Equal(t, buf.Len(), SerializedSize(ctx, ""))
+
+	h = http.Header{}
+	SerializeHeaders(WithEgressAllow(ctx, "Zone"), h, "")
+	assert.Equal(t, http.Header{}, h)
+
+	h = http.Header{}
+	SerializeCombined(ctx, h, "baggage")
+	assert.Equal(t, "tenant=acme", h.Get(W3CBaggageHeader))
+	assert.Equal(t, []string{"debug", "tenant", "zone"}, Keys(ctx), "the context keeps them")
+}
+
+func TestDeserializeAndJoin(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithReceipt(ctx, "charlie")
+	ctx = WithTTL(ctx, time.Second)
+
+	var responses []http.Header
+	for _, callee := range []func(context.Context) context.Context{alice, danny, elizabeth} {
+		h := http.Header{}
+		SerializeHeaders(callee(ctx), h, "")
+		responses = append(responses, h)
+	}
+	responses[1].Set("Ctx-Ttl", "200")
+
+	ctx = DeserializeAndJoin(ctx, responses...)
+	assert.Equal(t, []string{"alice", "bob", "charlie", "danny", "elizabeth"}, Receipts(ctx))
+	ttl, _ := TTL(ctx)
+	assert.Equal(t, 200*time.Millisecond, ttl)
+}
+
+func TestDeserializeHeadersAllow(t *testing.T) {
+	h := http.Header{}
+	h.Set("Ctx-Receipts", "alice")
+	h.Set("Content-Type", "text/plain")
+
+	ctx, err := DeserializeHeadersAllow(context.Background(), h, "Receipts", "TTL")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, Receipts(ctx))
+
+	h.Set("Ctx-Recepts", "mallory")
+	h.Set("Ctx-Debug", "true")
+	ctx, err = DeserializeHeadersAllow(context.Background(), h, "Receipts", "TTL")
+	assert.EqualError(t, err, "openctx: baggage keys not allowed: debug, recepts")
+	assert.False(t, HasBaggage(ctx))
+}
+
+func TestInjectRequest(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Internal-Zone", "a")
+	ctx = WithBaggage(ctx, "Token", "secret")
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	assert.NoError(t, err)
+	req = req.WithContext(ctx)
+
+	InjectRequest(req, "")
+	assert.Equal(t, "acme", req.Header.Get("Ctx-Tenant"))
+	assert.Equal(t, "a", req.Header.Get("Ctx-Internal-Zone"))
+
+	req.Header = http.Header{}
+	InjectRequest(req, "x-baggage-", StripPrefix("internal-"), func(ctx context.Context) context.Context {
+		return Remove(ctx, "Token")
+	})
+	assert.Equal(t, http.Header{
+		"X-Baggage-Tenant": {"acme"},
+		"X-Baggage-Zone":   {"a"},
+	}, req.Header)
+	_, ok := Baggage(req.Context(), "Token")
+	assert.True(t, ok, "the request context is unchanged")
+}
+
+func TestExtractRequest(t *testing.T) {
+	base := WithJoin(context.Background(), "Receipts", joinReceipts)
+	base = WithReceipt(base, "alice")
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	assert.NoError(t, err)
+	req = req.WithContext(base)
+	req.Header.Set("Ctx-Tenant", "acme")
+	req.Header.Add("Ctx-Receipts", "bob")
+	req.Header.Add("Ctx-Receipts", "charlie")
+
+	out := ExtractRequest(req)
+	assert.Equal(t, []string{"receipts", "tenant"}, Keys(out.Context()))
+	tenant, _ := Baggage(out.Context(), "Tenant")
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, []string{"alice", "bob", "charlie"}, Receipts(out.Context()))
+	assert.Equal(t, []string{"receipts"}, Keys(req.Context()), "the original request is unchanged")
+}
+
+func TestSerializeCombined(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	ctx = WithBaggage(ctx, "Note", "a, b; c")
+	h := http.Header{}
+	SerializeCombined(ctx, h, "baggage")
+	assert.Equal(t, http.Header{
+		"Baggage": {"note=a%2C%20b%3B%20c,region=us-east,tenant=acme"},
+	}, h)
+
+	out, err := DeserializeCombined(context.Background(), h, "Baggage")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"note", "region", "tenant"}, Keys(out))
+	note, _ := Baggage(out, "Note")
+	assert.Equal(t, "a, b; c", note)
+}
+
+func TestSerializeCombinedEgressAllow(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Token", "secret")
+	h := http.Header{}
+	SerializeCombined(WithEgressAllow(ctx, "Tenant"), h, "baggage")
+	assert.Equal(t, "tenant=acme", h.Get("Baggage"))
+
+	h = http.Header{}
+	SerializeCombined(context.Background(), h, "baggage")
+	assert.Empty(t, h)
+}
+
+func TestDeserializeCombined(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+	h := http.Header{}
+	h.Add("Baggage", "receipts=bob")
+	h.Add("Baggage", "tenant=acme")
+	ctx, err := DeserializeCombined(ctx, h, "baggage")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(ctx))
+	assert.Equal(t, []string{"receipts", "tenant"}, Keys(ctx))
+
+	h.Set("Baggage", "tenant")
+	_, err = DeserializeCombined(context.Background(), h, "baggage")
+	assert.Error(t, err)
+}
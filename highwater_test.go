@@ -0,0 +1,47 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestWithHighWaterMark(t *testing.T) {
+	ctx := WithHighWaterMark(context.Background(), "Queue-Depth", 4)
+	ctx = WithHighWaterMark(ctx, "Queue-Depth", 2)
+	depth, _ := Baggage(ctx, "Queue-Depth")
+	assert.Equal(t, "4", depth, "a lower observation keeps the mark")
+
+	a := WithHighWaterMark(ctx, "Queue-Depth", 7)
+	b := WithHighWaterMark(ctx, "Queue-Depth", 12)
+	c := WithHighWaterMark(ctx, "Queue-Depth", 3)
+	for _, merged := range []context.Context{
+		Join(Join(Join(ctx, a), b), c),
+		Join(Join(Join(ctx, c), b), a),
+	} {
+		depth, _ = Baggage(merged, "Queue-Depth")
+		assert.Equal(t, "12", depth)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ReservedKeyPrefix begins the baggage keys that the package reserves for
+// companion keys, which carry data about other properties across the wire.
+// Applications must not set keys with this prefix themselves; TryWithBaggage
+// rejects them with ErrReservedKey.
+const ReservedKeyPrefix = "openctx."
+
+// ErrReservedKey is returned by TryWithBaggage for a key that begins with
+// ReservedKeyPrefix.
+var ErrReservedKey = errors.New("openctx: baggage key is reserved")
+
+// The hop limit of a baggage property is carried as baggage itself, under a
+// reserved companion key named by this prefix and the property's key, so that
+// it crosses the wire alongside the property.
+const hopKeyPrefix = ReservedKeyPrefix + "hops."
+
+// WithHopLimit adds a baggage value like WithBaggage that is seen by at most
+// the given number of processes, including this one. Each time the value is
+// written by a codec, whether headers, text maps, the W3C, binary, string,
+// flag, or SQL comment forms, a propagator, or the Kafka, NATS, TChannel, or
+// OpenTracing packages, its remaining hop count is decremented on the wire,
+// and the reading codec drops the value once the count reaches zero. The count is
+// carried under a companion key, openctx.hops.<key>, which appears in Keys
+// with the value and is allowed wherever the key is allowed to egress.
+func WithHopLimit(ctx context.Context, key, value string, hops int) context.Context {
+	key = canonicalKey(key)
+	ctx = WithBaggage(ctx, key, value)
+	return WithBaggage(ctx, hopKeyPrefix+key, strconv.Itoa(hops))
+}
+
+// HopsRemaining returns the number of processes, including this one, that may
+// yet see a baggage value added with WithHopLimit.
+func HopsRemaining(ctx context.Context, key string) (hops int, ok bool) {
+	value, ok := Baggage(ctx, hopKeyPrefix+canonicalKey(key))
+	if !ok {
+		return 0, false
+	}
+	hops, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return hops, true
+}
+
+// outboundValue returns the value to write on the wire for a baggage key,
+// which for a hop count is one less than the count in this process.
+func outboundValue(key, value string) string {
+	if !strings.HasPrefix(key, hopKeyPrefix) {
+		return value
+	}
+	hops, err := strconv.Atoi(value)
+	if err != nil {
+		return value
+	}
+	return strconv.Itoa(hops - 1)
+}
+
+// DropExhausted returns a context without the baggage values whose hop limit
+// from WithHopLimit has been used up. Every codec in this package and its
+// subpackages does the same after reading baggage; a codec written elsewhere, which reads baggage with
+// WithBaggage, should call it too, and write baggage from OutboundEntries.
+func DropExhausted(ctx context.Context) context.Context {
+	return dropExhausted(ctx, Keys(ctx))
+}
+
+// dropExhausted removes the baggage for every hop count key among the given
+// keys whose count has reached zero, along with the count itself.
+func dropExhausted(ctx context.Context, keys []string) context.Context {
+	var drop []string
+	for _, key := range keys {
+		if !strings.HasPrefix(key, hopKeyPrefix) {
+			continue
+		}
+		value, _ := Baggage(ctx, key)
+		if hops, err := strconv.Atoi(value); err == nil && hops <= 0 {
+			drop = append(drop, key, key[len(hopKeyPrefix):])
+		}
+	}
+	if drop == nil {
+		return ctx
+	}
+	return RemoveAll(ctx, drop...)
+}
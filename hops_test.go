@@ -0,0 +1,160 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func TestWithHopLimit(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithHopLimit(ctx, "Debug", "verbose", 3)
+	hops, ok := HopsRemaining(ctx, "Debug")
+	assert.True(t, ok)
+	assert.Equal(t, 3, hops)
+
+	var seen []int
+	for Has(ctx, "Debug") {
+		hops, _ := HopsRemaining(ctx, "Debug")
+		seen = append(seen, hops)
+		h := http.Header{}
+		SerializeHeaders(ctx, h, "")
+		ctx = DeserializeHeaders(context.Background(), h, "")
+	}
+	assert.Equal(t, []int{3, 2, 1}, seen, "seen by three processes")
+	assert.Equal(t, []string{"tenant"}, Keys(ctx))
+	_, ok = HopsRemaining(ctx, "Debug")
+	assert.False(t, ok)
+}
+
+func TestWithHopLimitText(t *testing.T) {
+	ctx := WithHopLimit(context.Background(), "Debug", "verbose", 2)
+	carrier := MapCarrier{}
+	InjectText(ctx, carrier)
+	assert.Equal(t, "1", carrier["ctx-openctx.hops.debug"])
+	ctx = ExtractText(context.Background(), carrier)
+	debug, _ := Baggage(ctx, "Debug")
+	assert.Equal(t, "verbose", debug)
+
+	carrier = MapCarrier{}
+	InjectText(ctx, carrier)
+	assert.Equal(t, "0", carrier["ctx-openctx.hops.debug"])
+	ctx = ExtractText(context.Background(), carrier)
+	assert.Equal(t, []string{}, Keys(ctx))
+}
+
+func TestWithHopLimitSerializedSize(t *testing.T) {
+	ctx := WithHopLimit(context.Background(), "Debug", "verbose", 10)
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, "9", h.Get("Ctx-Openctx.hops.debug"))
+	size := 0
+	for name, values := range h {
+		size += headerSize("", name, values[0])
+	}
+	assert.Equal(t, size, SerializedSize(ctx, ""))
+}
+
+func TestWithHopLimitCodecs(t *testing.T) {
+	codecs := map[string]func(ctx context.Context) context.Context{
+		"W3C": func(ctx context.Context) context.Context {
+			out, err := UnmarshalW3CBaggage(context.Background(), MarshalW3CBaggage(ctx))
+			require.NoError(t, err)
+			return out
+		},
+		"combined": func(ctx context.Context) context.Context {
+			h := http.Header{}
+			SerializeCombined(ctx, h, W3CBaggageHeader)
+			out, err := DeserializeCombined(context.Background(), h, W3CBaggageHeader)
+			require.NoError(t, err)
+			return out
+		},
+		"binary": func(ctx context.Context) context.Context {
+			b, err := Marshal(ctx)
+			require.NoError(t, err)
+			out, err := Unmarshal(context.Background(), b)
+			require.NoError(t, err)
+			return out
+		},
+		"string": func(ctx context.Context) context.Context {
+			out, err := Parse(context.Background(), String(ctx))
+			require.NoError(t, err)
+			return out
+		},
+		"flag": func(ctx context.Context) context.Context {
+			out, err := ParseFlag(context.Background(), SerializeFlag(ctx))
+			require.NoError(t, err)
+			return out
+		},
+		"SQL comment": func(ctx context.Context) context.Context {
+			return ParseSQLComment(context.Background(), "SELECT 1 "+ToSQLComment(ctx))
+		},
+		"compressed": func(ctx context.Context) context.Context {
+			b, err := MarshalCompressed(ctx)
+			require.NoError(t, err)
+			out, err := UnmarshalCompressed(context.Background(), b)
+			require.NoError(t, err)
+			return out
+		},
+	}
+	for name, codec := range codecs {
+		ctx := WithBaggage(context.Background(), "Tenant", "acme")
+		ctx = WithHopLimit(ctx, "Debug", "verbose", 2)
+		ctx = codec(ctx)
+		hops, _ := HopsRemaining(ctx, "Debug")
+		assert.Equal(t, 1, hops, name)
+		ctx = codec(ctx)
+		assert.Equal(t, []string{"tenant"}, Keys(ctx), name)
+	}
+}
+
+func TestHopLimitEgressAllow(t *testing.T) {
+	ctx := WithHopLimit(context.Background(), "Debug", "verbose", 2)
+	ctx = WithBaggage(ctx, "Token", "secret")
+	h := http.Header{}
+	SerializeHeaders(WithEgressAllow(ctx, "Debug"), h, "")
+	assert.Equal(t, http.Header{
+		"Ctx-Debug":              {"verbose"},
+		"Ctx-Openctx.hops.debug": {"1"},
+	}, h, "the hop count egresses with its key")
+}
+
+func TestHopLimitIngressAllow(t *testing.T) {
+	h := http.Header{}
+	SerializeHeaders(WithHopLimit(context.Background(), "Region", "us-east", 2), h, "")
+	ctx, err := DeserializeHeadersAllow(context.Background(), h, "region")
+	require.NoError(t, err, "the hop count is allowed with its key")
+	hops, _ := HopsRemaining(ctx, "Region")
+	assert.Equal(t, 1, hops)
+}
+
+func TestReservedKey(t *testing.T) {
+	ctx, err := TryWithBaggage(context.Background(), "OpenCtx.Hops.Debug", "100")
+	assert.Equal(t, ErrReservedKey, err)
+	assert.False(t, HasBaggage(ctx))
+}
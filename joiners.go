@@ -0,0 +1,334 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The following are join functions for common kinds of baggage, suitable for
+// WithJoin and WithBaggageJoin.
+
+// JoinJSONMerge joins two baggage values that are JSON objects by merging
+// their top level properties, taking properties from b where both have them.
+// If either value is not a JSON object, b wins.
+func JoinJSONMerge(a, b string) string {
+	var aobj, bobj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(a), &aobj); err != nil || aobj == nil {
+		return b
+	}
+	if err := json.Unmarshal([]byte(b), &bobj); err != nil || bobj == nil {
+		return b
+	}
+	for key, value := range bobj {
+		aobj[key] = value
+	}
+	merged, err := json.Marshal(aobj)
+	if err != nil {
+		return b
+	}
+	return string(merged)
+}
+
+// JoinMin joins two baggage values by taking the lesser. Values are compared
+// as 64 bit integers, so "9" is less than "100". If either value is not an
+// integer, they are compared as strings instead.
+func JoinMin(a, b string) string {
+	if compareNumeric(a, b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// JoinMax joins two baggage values by taking the greater. Values are compared
+// as 64 bit integers, so "100" is greater than "9". If either value is not an
+// integer, they are compared as strings instead.
+func JoinMax(a, b string) string {
+	if compareNumeric(a, b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// JoinNumericMax joins two baggage values by taking the greater as a 64 bit
+// integer, for high-water marks. Unlike JoinMax, a value that is not an
+// integer is ignored rather than compared as a string; if neither is an
+// integer, b wins.
+func JoinNumericMax(a, b string) string {
+	an, aerr := strconv.ParseInt(a, 10, 64)
+	bn, berr := strconv.ParseInt(b, 10, 64)
+	if aerr == nil && (berr != nil || an > bn) {
+		return a
+	}
+	return b
+}
+
+// JoinByPriority returns a factory of join functions that keep the value with
+// the higher priority by a priority function, which compares two values for a
+// key and returns a non-negative number to keep a, or a negative number to
+// keep b. Since join functions are not told their key, the factory binds one:
+//
+//	longer := JoinByPriority(func(key, a, b string) int { return len(a) - len(b) })
+//	ctx = WithJoin(ctx, "Region", longer("Region"))
+//
+// The key is canonicalized before it is passed to the priority function.
+func JoinByPriority(priority func(key, a, b string) int) func(key string) func(a, b string) string {
+	return func(key string) func(a, b string) string {
+		key = canonicalKey(key)
+		return func(a, b string) string {
+			if priority(key, a, b) >= 0 {
+				return a
+			}
+			return b
+		}
+	}
+}
+
+// JoinSum joins two baggage values by adding them as 64 bit integers. If
+// either value is not an integer, the greater as a string is taken instead,
+// as by JoinMax.
+func JoinSum(a, b string) string {
+	an, aerr := strconv.ParseInt(a, 10, 64)
+	bn, berr := strconv.ParseInt(b, 10, 64)
+	if aerr != nil || berr != nil {
+		return JoinMax(a, b)
+	}
+	return strconv.FormatInt(an+bn, 10)
+}
+
+// JoinOr joins two baggage values that are booleans, as parsed by
+// strconv.ParseBool, by taking their logical or, formatted as "true" or
+// "false". A value that is not a boolean is ignored; if neither is a boolean,
+// b wins.
+func JoinOr(a, b string) string {
+	ab, aerr := strconv.ParseBool(a)
+	bb, berr := strconv.ParseBool(b)
+	if aerr != nil && berr != nil {
+		return b
+	}
+	return strconv.FormatBool(ab || bb)
+}
+
+// JoinProbabilityMax joins two baggage values that are sampling probabilities
+// by taking the greater, clamped to the range 0 through 1. Max is the safe
+// choice for sampling: a branch that decided to sample at a higher rate needs
+// the traces from every other branch to be complete, while taking the min
+// would silently drop spans that some branch expected to keep. A value that
+// is not a number is ignored; if neither is a number, b wins.
+func JoinProbabilityMax(a, b string) string {
+	ap, aok := parseProbability(a)
+	bp, bok := parseProbability(b)
+	switch {
+	case aok && bok:
+		if bp > ap {
+			ap = bp
+		}
+	case bok:
+		ap = bp
+	case !aok:
+		return b
+	}
+	return strconv.FormatFloat(ap, 'g', -1, 64)
+}
+
+// parseProbability parses a probability, clamping it to the range 0 through
+// 1.
+func parseProbability(s string) (float64, bool) {
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(p) {
+		return 0, false
+	}
+	return math.Max(0, math.Min(1, p)), true
+}
+
+// compareNumeric compares two values as integers if both parse, otherwise as
+// strings, returning -1, 0, or 1.
+func compareNumeric(a, b string) int {
+	an, aerr := strconv.ParseInt(a, 10, 64)
+	bn, berr := strconv.ParseInt(b, 10, 64)
+	if aerr != nil || berr != nil {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case an < bn:
+		return -1
+	case an > bn:
+		return 1
+	}
+	return 0
+}
+
+// JoinUnion returns a join function for baggage values that are lists of
+// elements delimited by a separator, like receipts delimited by ", ". The join
+// takes the sorted union of the elements of both values. Elements must not
+// contain the separator; see JoinUnionEscaped. With an empty separator, each
+// value is a single element, and since two elements cannot be written as one
+// list, the later non-empty value wins.
+func JoinUnion(sep string) func(a, b string) string {
+	if sep == "" {
+		return joinUnseparated
+	}
+	return func(a, b string) string {
+		return formatUnion(append(strings.Split(a, sep), strings.Split(b, sep)...), sep)
+	}
+}
+
+// JoinUnionFold is like JoinUnion, but treats elements that differ only in
+// case as the same element, keeping the casing first seen, in a and then in
+// b. The union is sorted without regard to case. An empty separator is
+// treated as by JoinUnion.
+func JoinUnionFold(sep string) func(a, b string) string {
+	if sep == "" {
+		return joinUnseparated
+	}
+	return func(a, b string) string {
+		elements := append(strings.Split(a, sep), strings.Split(b, sep)...)
+		seen := make(map[string]struct{}, len(elements))
+		union := make([]string, 0, len(elements))
+		for _, element := range elements {
+			folded := strings.ToLower(element)
+			if _, ok := seen[folded]; ok || element == "" {
+				continue
+			}
+			seen[folded] = struct{}{}
+			union = append(union, element)
+		}
+		sort.Slice(union, func(i, j int) bool {
+			return strings.ToLower(union[i]) < strings.ToLower(union[j])
+		})
+		return strings.Join(union, sep)
+	}
+}
+
+// JoinUnionEscaped is like JoinUnion for lists whose elements may contain the
+// separator, escaped with EscapeUnion. Elements keep their escapes in the
+// joined value, and SplitUnion recovers them. An empty separator is treated
+// as by JoinUnion.
+func JoinUnionEscaped(sep string) func(a, b string) string {
+	if sep == "" {
+		return joinUnseparated
+	}
+	return func(a, b string) string {
+		return formatUnion(append(splitEscaped(a, sep), splitEscaped(b, sep)...), sep)
+	}
+}
+
+// joinUnseparated joins two lists without a separator, each a single element,
+// by taking b unless it is empty.
+func joinUnseparated(a, b string) string {
+	if b == "" {
+		return a
+	}
+	return b
+}
+
+// EscapeUnion escapes an element for a list joined by JoinUnionEscaped,
+// preceding each backslash and each occurrence of the separator with a
+// backslash.
+func EscapeUnion(element, sep string) string {
+	element = strings.Replace(element, `\`, `\\`, -1)
+	if sep == "" {
+		return element
+	}
+	return strings.Replace(element, sep, `\`+sep, -1)
+}
+
+// SplitUnion splits a list joined by JoinUnionEscaped into its unescaped
+// elements.
+func SplitUnion(value, sep string) []string {
+	elements := splitEscaped(value, sep)
+	for i, element := range elements {
+		elements[i] = unescapeUnion(element)
+	}
+	return elements
+}
+
+// splitEscaped splits a value at each separator that is not escaped, leaving
+// escapes within the elements.
+func splitEscaped(value, sep string) []string {
+	if sep == "" {
+		return []string{value}
+	}
+	var elements []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\':
+			i++
+		case strings.HasPrefix(value[i:], sep):
+			elements = append(elements, value[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(elements, value[start:])
+}
+
+func unescapeUnion(element string) string {
+	if strings.IndexByte(element, '\\') < 0 {
+		return element
+	}
+	buf := make([]byte, 0, len(element))
+	for i := 0; i < len(element); i++ {
+		if element[i] == '\\' && i+1 < len(element) {
+			i++
+		}
+		buf = append(buf, element[i])
+	}
+	return string(buf)
+}
+
+// formatUnion joins the distinct, non-empty elements in sorted order.
+func formatUnion(elements []string, sep string) string {
+	set := make(map[string]struct{}, len(elements))
+	for _, element := range elements {
+		if element != "" {
+			set[element] = struct{}{}
+		}
+	}
+	union := make([]string, 0, len(set))
+	for element := range set {
+		union = append(union, element)
+	}
+	sort.Strings(union)
+	return strings.Join(union, sep)
+}
+
+// JoinDeterministicPick joins two baggage values by picking the lesser as
+// strings. Unlike last-value-wins, every order of joining the same values
+// picks the same one, so independent branches converge, as they must for a
+// shard key.
+func JoinDeterministicPick(a, b string) string {
+	if b < a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestJoinJSONMerge(t *testing.T) {
+	assert.Equal(t,
+		`{"a":1,"b":{"y":2},"c":3}`,
+		JoinJSONMerge(`{"a":1,"b":{"x":1}}`, `{"b":{"y":2},"c":3}`),
+	)
+	assert.Equal(t, `{"a":1}`, JoinJSONMerge(`{}`, `{"a":1}`))
+}
+
+func TestJoinJSONMergeNotObject(t *testing.T) {
+	assert.Equal(t, `{"a":1}`, JoinJSONMerge("plain", `{"a":1}`))
+	assert.Equal(t, "plain", JoinJSONMerge(`{"a":1}`, "plain"))
+	assert.Equal(t, `[1]`, JoinJSONMerge(`{"a":1}`, `[1]`))
+	assert.Equal(t, `{"a":1}`, JoinJSONMerge(`null`, `{"a":1}`))
+}
+
+func TestJoinJSONMergeInContext(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "Flags", JoinJSONMerge)
+	ctx = WithBaggage(ctx, "Flags", `{"dark":true}`)
+	ctx = WithBaggage(ctx, "Flags", `{"beta":false}`)
+	flags, _ := Baggage(ctx, "Flags")
+	assert.Equal(t, `{"beta":false,"dark":true}`, flags)
+}
+
+func TestJoinMinMaxNumeric(t *testing.T) {
+	assert.Equal(t, "9", JoinMin("9", "100"))
+	assert.Equal(t, "9", JoinMin("100", "9"))
+	assert.Equal(t, "100", JoinMax("9", "100"))
+	assert.Equal(t, "100", JoinMax("100", "9"))
+	assert.Equal(t, "-5", JoinMin("-5", "3"))
+	assert.Equal(t, "9223372036854775807", JoinMax("9223372036854775807", "1"))
+}
+
+func TestJoinMinMaxLexicalFallback(t *testing.T) {
+	assert.Equal(t, "100", JoinMin("100", "9x"))
+	assert.Equal(t, "9x", JoinMax("100", "9x"))
+	assert.Equal(t, "apple", JoinMin("banana", "apple"))
+	assert.Equal(t, "banana", JoinMax("banana", "apple"))
+}
+
+func TestJoinSum(t *testing.T) {
+	assert.Equal(t, "109", JoinSum("9", "100"))
+	assert.Equal(t, "-2", JoinSum("-5", "3"))
+	assert.Equal(t, "9x", JoinSum("100", "9x"), "lexical fallback")
+	assert.Equal(t, "banana", JoinSum("apple", "banana"))
+}
+
+func TestJoinNumericMax(t *testing.T) {
+	assert.Equal(t, "100", JoinNumericMax("9", "100"))
+	assert.Equal(t, "100", JoinNumericMax("100", "9"))
+	assert.Equal(t, "-3", JoinNumericMax("-5", "-3"))
+	assert.Equal(t, "9", JoinNumericMax("9", "high"), "non-numeric ignored")
+	assert.Equal(t, "9", JoinNumericMax("high", "9"))
+	assert.Equal(t, "low", JoinNumericMax("high", "low"))
+}
+
+func TestJoinOr(t *testing.T) {
+	assert.Equal(t, "true", JoinOr("true", "false"))
+	assert.Equal(t, "true", JoinOr("0", "1"))
+	assert.Equal(t, "false", JoinOr("false", "F"))
+	assert.Equal(t, "true", JoinOr("maybe", "true"), "non-boolean ignored")
+	assert.Equal(t, "false", JoinOr("false", "maybe"))
+	assert.Equal(t, "never", JoinOr("maybe", "never"))
+}
+
+func TestJoinByPriority(t *testing.T) {
+	var keys []string
+	longer := JoinByPriority(func(key, a, b string) int {
+		keys = append(keys, key)
+		return len(a) - len(b)
+	})
+	join := longer("Region")
+	assert.Equal(t, "us-east", join("us-east", "us"))
+	assert.Equal(t, "us-east", join("us", "us-east"))
+	assert.Equal(t, "us", join("us", "eu"), "ties keep a")
+	assert.Equal(t, []string{"region", "region", "region"}, keys)
+
+	ctx := WithJoin(context.Background(), "Region", join)
+	ctx = WithBaggage(ctx, "Region", "eu")
+	branch := WithBaggage(ctx, "Region", "eu-west-1")
+	ctx = Join(ctx, branch)
+	ctx = WithBaggage(ctx, "Region", "us")
+	region, _ := Baggage(ctx, "Region")
+	assert.Equal(t, "eu-west-1", region)
+}
+
+func TestJoinProbabilityMax(t *testing.T) {
+	assert.Equal(t, "0.5", JoinProbabilityMax("0.1", "0.5"))
+	assert.Equal(t, "0.5", JoinProbabilityMax("0.5", "0.1"))
+	assert.Equal(t, "1", JoinProbabilityMax("0.5", "1.5"), "clamped above")
+	assert.Equal(t, "0", JoinProbabilityMax("-2", "-0.5"), "clamped below")
+	assert.Equal(t, "0.25", JoinProbabilityMax("always", "0.25"), "non-numeric ignored")
+	assert.Equal(t, "0.25", JoinProbabilityMax("0.25", "NaN"))
+	assert.Equal(t, "never", JoinProbabilityMax("always", "never"))
+}
+
+func TestJoinMinInContext(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "Deadline-Ms", JoinMin)
+	ctx = WithBaggage(ctx, "Deadline-Ms", "100")
+	ctx = WithBaggage(ctx, "Deadline-Ms", "9")
+	ctx = WithBaggage(ctx, "Deadline-Ms", "50")
+	deadline, _ := Baggage(ctx, "Deadline-Ms")
+	assert.Equal(t, "9", deadline)
+}
+
+func TestJoinUnion(t *testing.T) {
+	join := JoinUnion(", ")
+	assert.Equal(t, "a, b", join("a", "b"))
+	assert.Equal(t, "a, b, c, d", join("a, c", "b, d"))
+	assert.Equal(t, "a, b", join("b, a", "a"))
+	assert.Equal(t, "a", join("", "a"))
+	assert.Equal(t, "a|b|c", JoinUnion("|")("c|a", "b"))
+}
+
+func TestJoinUnionFold(t *testing.T) {
+	join := JoinUnionFold(", ")
+	assert.Equal(t, "Alice", join("Alice", "alice"))
+	assert.Equal(t, "alice", join("alice", "ALICE"))
+	assert.Equal(t, "Alice, bob, carol", join("bob, Alice", "carol, BOB, Carol"))
+	assert.Equal(t, "a", join("", "a"))
+}
+
+func TestJoinUnionSeparatorInElement(t *testing.T) {
+	join := JoinUnion(",")
+	assert.Equal(t, "a,b,c", join("a,b", "c"), "the element is split apart")
+
+	join = JoinUnionEscaped(",")
+	joined := join(EscapeUnion("a,b", ","), EscapeUnion("c", ","))
+	assert.Equal(t, `a\,b,c`, joined)
+	joined = join(joined, EscapeUnion(`back\slash`, ","))
+	assert.Equal(t, []string{"a,b", `back\slash`, "c"}, SplitUnion(joined, ","))
+	assert.Equal(t, joined, join(joined, EscapeUnion("a,b", ",")), "escaped duplicates collapse")
+}
+
+func TestJoinUnionEmptySeparator(t *testing.T) {
+	for name, join := range map[string]func(a, b string) string{
+		"JoinUnion":        JoinUnion(""),
+		"JoinUnionFold":    JoinUnionFold(""),
+		"JoinUnionEscaped": JoinUnionEscaped(""),
+	} {
+		assert.Equal(t, "c", join("ab", "c"), name)
+		assert.Equal(t, "ab", join("ab", "ab"), name)
+		assert.Equal(t, "ab", join("ab", ""), name)
+		assert.Equal(t, "ab", join("", "ab"), name)
+	}
+}
+
+func TestJoinUnionEscapedMultiByteSeparator(t *testing.T) {
+	join := JoinUnionEscaped(", ")
+	joined := join(EscapeUnion("Doe, Jane", ", "), EscapeUnion("alice", ", "))
+	assert.Equal(t, `Doe\, Jane, alice`, joined)
+	assert.Equal(t, []string{"Doe, Jane", "alice"}, SplitUnion(joined, ", "))
+}
+
+func TestJoinDeterministicPick(t *testing.T) {
+	assert.Equal(t, "a", JoinDeterministicPick("a", "b"))
+	assert.Equal(t, "a", JoinDeterministicPick("b", "a"))
+	assert.Equal(t, "100", JoinDeterministicPick("9", "100"), "compared as strings")
+
+	ctx := WithJoin(context.Background(), "Shard", JoinDeterministicPick)
+	branches := []context.Context{
+		WithBaggage(ctx, "Shard", "shard-7"),
+		WithBaggage(ctx, "Shard", "shard-3"),
+		WithBaggage(ctx, "Shard", "shard-5"),
+	}
+	for _, perm := range permutations(branches) {
+		shard, _ := Baggage(JoinAll(ctx, perm...), "Shard")
+		assert.Equal(t, "shard-3", shard)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.18
+// +build go1.18
+
+package kafkactx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/openctx/openctx-go"
+
+	"golang.org/x/net/context"
+)
+
+// FuzzFromKafkaHeaders feeds an arbitrary header to FromKafkaHeaders, which
+// must not panic. Where the header decodes, encoding the result and decoding
+// it again must reproduce the same headers.
+func FuzzFromKafkaHeaders(f *testing.F) {
+	f.Add("tenant", []byte("acme"))
+	f.Add("", []byte{})
+	f.Add("note", []byte{0xff, 0xfe})
+	f.Fuzz(func(t *testing.T, key string, value []byte) {
+		ctx := FromKafkaHeaders(context.Background(), Header{Key: key, Value: value})
+		for _, key := range openctx.Keys(ctx) {
+			if strings.HasPrefix(key, openctx.ReservedKeyPrefix) {
+				return // hop limits count down on every encoding
+			}
+		}
+		encoded := ToKafkaHeaders(ctx)
+		reencoded := ToKafkaHeaders(FromKafkaHeaders(context.Background(), encoded...))
+		if len(encoded) != len(reencoded) {
+			t.Fatalf("%q re-encoded as %q", encoded, reencoded)
+		}
+		for i := range encoded {
+			if encoded[i].Key != reencoded[i].Key || !bytes.Equal(encoded[i].Value, reencoded[i].Value) {
+				t.Fatalf("%q re-encoded as %q", encoded, reencoded)
+			}
+		}
+	})
+}
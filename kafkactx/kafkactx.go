@@ -0,0 +1,66 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package kafkactx carries Open Context baggage over Kafka record headers.
+//
+// Header has the same shape as the record header types of the common Kafka
+// clients, so a segmentio kafka.Header converts directly, and a sarama
+// RecordHeader needs only its key converted between string and []byte.
+package kafkactx
+
+import (
+	"unicode/utf8"
+
+	"github.com/openctx/openctx-go"
+
+	"golang.org/x/net/context"
+)
+
+// Header is a single Kafka record header.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// ToKafkaHeaders returns the baggage carried by a context as Kafka record
+// headers, in sorted key order, as written by openctx.OutboundEntries.
+func ToKafkaHeaders(ctx context.Context) []Header {
+	entries := openctx.OutboundEntries(ctx)
+	headers := make([]Header, 0, len(entries))
+	for _, entry := range entries {
+		headers = append(headers, Header{Key: entry.Key, Value: []byte(entry.Value)})
+	}
+	return headers
+}
+
+// FromKafkaHeaders returns a context carrying the baggage from Kafka record
+// headers. Kafka permits repeated header keys, so each header is joined in
+// order with any prior value through the join functions in context. Headers
+// with values that are not valid UTF-8 cannot be baggage and are skipped.
+// Values whose hop limit is used up are dropped.
+func FromKafkaHeaders(ctx context.Context, headers ...Header) context.Context {
+	for _, header := range headers {
+		if !utf8.Valid(header.Value) {
+			continue
+		}
+		ctx = openctx.WithBaggage(ctx, header.Key, string(header.Value))
+	}
+	return openctx.DropExhausted(ctx)
+}
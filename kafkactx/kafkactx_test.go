@@ -0,0 +1,82 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafkactx
+
+import (
+	"testing"
+
+	"github.com/openctx/openctx-go"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func joinReceipts(a, b string) string {
+	return a + ", " + b
+}
+
+func TestKafkaHeadersRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "TTL", "1000")
+	ctx = openctx.WithBaggage(ctx, "Receipts", "alice")
+	headers := ToKafkaHeaders(ctx)
+	assert.Equal(t, []Header{
+		{Key: "receipts", Value: []byte("alice")},
+		{Key: "ttl", Value: []byte("1000")},
+	}, headers)
+
+	out := FromKafkaHeaders(context.Background(), headers...)
+	assert.Equal(t, []string{"receipts", "ttl"}, openctx.Keys(out))
+	assert.Equal(t, headers, ToKafkaHeaders(out))
+}
+
+func TestFromKafkaHeadersJoins(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithJoin(ctx, "receipts", joinReceipts)
+	ctx = FromKafkaHeaders(ctx,
+		Header{Key: "receipts", Value: []byte("alice")},
+		Header{Key: "receipts", Value: []byte("bob")},
+	)
+	receipts, ok := openctx.Baggage(ctx, "receipts")
+	assert.True(t, ok)
+	assert.Equal(t, "alice, bob", receipts)
+}
+
+func TestFromKafkaHeadersSkipsInvalidUTF8(t *testing.T) {
+	ctx := FromKafkaHeaders(context.Background(),
+		Header{Key: "binary-payload", Value: []byte{0xff, 0xfe}},
+	)
+	_, ok := openctx.Baggage(ctx, "binary-payload")
+	assert.False(t, ok)
+}
+
+func TestKafkaHeadersHopLimit(t *testing.T) {
+	ctx := openctx.WithHopLimit(context.Background(), "Debug", "verbose", 2)
+	ctx = openctx.WithBaggage(ctx, "Token", "secret")
+	ctx = openctx.WithEgressAllow(ctx, "Debug")
+	ctx = FromKafkaHeaders(context.Background(), ToKafkaHeaders(ctx)...)
+	assert.Equal(t, []string{"debug", "openctx.hops.debug"}, openctx.Keys(ctx))
+	hops, _ := openctx.HopsRemaining(ctx, "Debug")
+	assert.Equal(t, 1, hops)
+
+	ctx = FromKafkaHeaders(context.Background(), ToKafkaHeaders(ctx)...)
+	assert.Equal(t, []string{}, openctx.Keys(ctx))
+}
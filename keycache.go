@@ -0,0 +1,74 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// A valueContext carries a single baggage value, like a context from
+// context.WithValue, and caches the result of Keys. A context never changes
+// once made, and every change to baggage makes a new context, so the cached
+// keys stay valid. Learning new keys globally does not invalidate them, since
+// a context cannot carry a key that was unknown when it was made, other than
+// an ephemeral key found through its own order.
+type valueContext struct {
+	context.Context
+	bkey  baggageKey
+	value string
+	joins int
+
+	mu         sync.Mutex
+	cachedKeys []string
+}
+
+func (c *valueContext) Value(key interface{}) interface{} {
+	switch key := key.(type) {
+	case baggageKey:
+		if key == c.bkey {
+			return c.value
+		}
+	case joinCountKey:
+		if baggageKey(key) == c.bkey && c.joins > 0 {
+			return c.joins
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// keys returns a copy of the cached result of Keys for the context, computing
+// it on first use.
+func (c *valueContext) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cachedKeys == nil {
+		keys, cacheable := scanKeys(c)
+		if !cacheable {
+			return keys
+		}
+		c.cachedKeys = keys
+	}
+	keys := make([]string, len(c.cachedKeys))
+	copy(keys, c.cachedKeys)
+	return keys
+}
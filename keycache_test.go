@@ -0,0 +1,78 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestKeysCached(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	keys := Keys(ctx)
+	assert.Equal(t, []string{"region", "tenant"}, keys)
+	keys[0] = "mutated"
+	assert.Equal(t, []string{"region", "tenant"}, Keys(ctx), "callers get a copy")
+
+	assert.Equal(t, []string{"tenant"}, Keys(Remove(ctx, "Region")))
+	assert.Equal(t, []string{"region", "tenant", "zone"}, Keys(WithBaggage(ctx, "Zone", "a")))
+	assert.Equal(t, []string{"region", "tenant"}, Keys(ctx))
+	assert.Equal(t, []string{}, Keys(StripAll(ctx)))
+}
+
+func TestKeysNotCachedWithExpiry(t *testing.T) {
+	advance, restore := useFakeClock()
+	defer restore()
+	ctx := WithBaggageExpiry(context.Background(), "Token", "t1", now().Add(time.Second))
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	assert.Equal(t, []string{"tenant", "token"}, Keys(ctx))
+	advance(time.Second)
+	assert.Equal(t, []string{"tenant"}, Keys(ctx))
+}
+
+func BenchmarkKeys(b *testing.B) {
+	for i := 0; i < 100; i++ {
+		learnKey(baggageKey(fmt.Sprintf("bench-known-%d", i)))
+	}
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		ctx = WithBaggage(ctx, fmt.Sprintf("bench-keys-%d", i), "value")
+	}
+
+	b.Run("scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			scanKeys(ctx)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Keys(ctx)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// JoinLazy joins two contexts like Join, but defers merging each baggage
+// property until it is first read, and then remembers the result. Deep fan-in
+// chains where few keys are read after the join avoid merging the rest. Keys
+// still reports the union of both contexts. The join functions and seals of
+// this context apply, as with Join.
+func JoinLazy(this context.Context, that context.Context) context.Context {
+	if !HasBaggage(that) {
+		return this
+	}
+	return &lazyContext{
+		Context: this,
+		that:    that,
+		merged:  make(map[baggageKey]interface{}),
+		joins:   make(map[baggageKey]struct{}),
+	}
+}
+
+// A lazyContext carries the later context of a join and merges its baggage
+// into the earlier context on demand.
+type lazyContext struct {
+	context.Context
+	that context.Context
+
+	mu     sync.Mutex
+	merged map[baggageKey]interface{}
+	joins  map[baggageKey]struct{}
+	order  []baggageKey
+}
+
+func (c *lazyContext) Value(key interface{}) interface{} {
+	switch key := key.(type) {
+	case baggageKey:
+		return c.mergedValue(key)
+	case orderKey:
+		return c.mergedOrder()
+	case joinCountKey:
+		bkey := baggageKey(key)
+		c.mergedValue(bkey)
+		n, _ := c.Context.Value(key).(int)
+		c.mu.Lock()
+		if _, ok := c.joins[bkey]; ok {
+			n++
+		}
+		c.mu.Unlock()
+		return n
+	case provenanceKey:
+		bkey := baggageKey(key)
+		if p, ok := c.that.Value(key).(provenance); ok {
+			if value, _ := liveValue(c.mergedValue(bkey)); value == p.value {
+				if _, ok := lookupProvenance(c.that, bkey); ok {
+					return p
+				}
+			}
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// mergedValue returns the value stored under a baggage key once the later
+// context is joined, which may be a tombstone or nil if neither context
+// carries the key.
+func (c *lazyContext) mergedValue(bkey baggageKey) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok := c.merged[bkey]; ok {
+		return value
+	}
+	value := c.Context.Value(bkey)
+	if later, ok := lookup(c.that, bkey); ok && !isSealed(c.Context, bkey) {
+		prior, hasPrior := liveValue(value)
+		join := joinFor(c.Context, bkey)
+		value = later
+		if hasPrior && join != nil {
+			later = callJoin(bkey, join, prior, later)
+			joined(bkey)
+			c.joins[bkey] = struct{}{}
+			value = later
+			if removesKey(later) {
+				value = tombstone{}
+			}
+		}
+	}
+	c.merged[bkey] = value
+	return value
+}
+
+// mergedOrder returns the keys set on the earlier context followed by those
+// first set on the later context.
+func (c *lazyContext) mergedOrder() []baggageKey {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.order != nil {
+		return c.order
+	}
+	order, _ := c.Context.Value(orderKey{}).([]baggageKey)
+	later, _ := c.that.Value(orderKey{}).([]baggageKey)
+	c.order = make([]baggageKey, len(order), len(order)+len(later))
+	copy(c.order, order)
+	for _, bkey := range later {
+		if c.Context.Value(bkey) == nil {
+			c.order = append(c.order, bkey)
+		}
+	}
+	return c.order
+}
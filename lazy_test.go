@@ -0,0 +1,146 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestJoinLazyMatchesJoin(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithTTL(ctx, time.Second)
+	ctx = WithBaggage(ctx, "Shard", "a")
+	ctx = WithReceipt(ctx, "charlie")
+
+	responses := []context.Context{
+		alice(ctx),
+		WithBaggage(danny(ctx), "Shard", "d"),
+		WithTTL(elizabeth(ctx), 100*time.Millisecond),
+		Remove(ctx, "Shard"),
+	}
+	for _, perm := range permutations(responses) {
+		eager, lazy := ctx, ctx
+		for _, response := range perm {
+			eager = Join(eager, response)
+			lazy = JoinLazy(lazy, response)
+		}
+		assert.Equal(t, Keys(eager), Keys(lazy))
+		assert.Equal(t, KeysOrdered(eager), KeysOrdered(lazy))
+		for _, key := range Keys(eager) {
+			want, _ := Baggage(eager, key)
+			got, ok := Baggage(lazy, key)
+			assert.True(t, ok, key)
+			assert.Equal(t, want, got, key)
+		}
+	}
+}
+
+func TestJoinLazyUnion(t *testing.T) {
+	this := WithBaggage(context.Background(), "Tenant", "acme")
+	that := WithBaggage(context.Background(), "Region", "us-east")
+	ctx := JoinLazy(this, that)
+	assert.Equal(t, []string{"region", "tenant"}, Keys(ctx))
+	assert.Equal(t, []string{"tenant", "region"}, KeysOrdered(ctx))
+	assert.True(t, HasBaggage(ctx))
+
+	assert.Equal(t, this, JoinLazy(this, context.Background()))
+}
+
+func TestJoinLazySealed(t *testing.T) {
+	this := Seal(WithBaggage(context.Background(), "Tenant", "acme"), "Tenant")
+	that := WithBaggage(context.Background(), "Tenant", "evil")
+	tenant, _ := Baggage(JoinLazy(this, that), "Tenant")
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestJoinLazyProvenance(t *testing.T) {
+	this := WithBaggageFrom(context.Background(), "Shard", "a", "alice")
+	that := WithBaggageFrom(context.Background(), "Shard", "b", "bob")
+	source, ok := Provenance(JoinLazy(this, that), "Shard")
+	assert.True(t, ok)
+	assert.Equal(t, "bob", source)
+}
+
+func BenchmarkJoinFanIn(b *testing.B) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	responses := make([]context.Context, 50)
+	for i := range responses {
+		response := WithReceipt(ctx, fmt.Sprintf("service-%d", i))
+		for j := 0; j < 20; j++ {
+			response = WithBaggage(response, fmt.Sprintf("fan-in-%d", j), fmt.Sprintf("%d", i))
+		}
+		responses[i] = response
+	}
+
+	b.Run("eager", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			merged := ctx
+			for _, response := range responses {
+				merged = Join(merged, response)
+			}
+			Baggage(merged, "fan-in-0")
+		}
+	})
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			merged := ctx
+			for _, response := range responses {
+				merged = JoinLazy(merged, response)
+			}
+			Baggage(merged, "fan-in-0")
+		}
+	})
+}
+
+func TestJoinLazyJoinLearnsKey(t *testing.T) {
+	learning := func(a, b string) string {
+		WithBaggage(context.Background(), "lazy-learned-"+a+b, "x")
+		return a + b
+	}
+	this := WithJoin(context.Background(), "Lazy-Note", learning)
+	this = WithBaggage(this, "Lazy-Note", "a")
+	that := WithBaggage(context.Background(), "Lazy-Note", "b")
+
+	done := make(chan []string)
+	go func() {
+		lazy := JoinLazy(this, that)
+		UnionKeys(lazy)
+		JoinableKeys(JoinLazy(this, that))
+		done <- Keys(JoinLazy(this, that))
+	}()
+	select {
+	case keys := <-done:
+		assert.Contains(t, keys, "lazy-note")
+	case <-time.After(5 * time.Second):
+		t.Fatal("a join function that learns a key deadlocked")
+	}
+}
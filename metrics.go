@@ -0,0 +1,74 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/net/context"
+)
+
+// The join metrics hook is stored atomically, so that it may be read by any
+// number of goroutines.
+var joinMetrics atomic.Value
+
+// SetJoinMetrics installs a hook that is called with the key each time a join
+// function merges two baggage values, whether through WithBaggage,
+// WithBaggageJoin, or Join. The hook may be called from many goroutines at
+// once and should be cheap, like incrementing a counter. Setting nil removes
+// the hook.
+func SetJoinMetrics(fn func(key string)) {
+	joinMetrics.Store(fn)
+}
+
+// joined reports a join to the join metrics hook, if any.
+func joined(bkey baggageKey) {
+	if fn, _ := joinMetrics.Load().(func(key string)); fn != nil {
+		fn(string(bkey))
+	}
+}
+
+// The number of times a join function has merged values for a key on a
+// context's chain is answered for a join count key by the context that
+// stored the joined value, so counting adds no context to the chain.
+type joinCountKey string
+
+// joinCount returns how many joins have merged the value for a key.
+func joinCount(ctx context.Context, bkey baggageKey) int {
+	n, _ := ctx.Value(joinCountKey(bkey)).(int)
+	return n
+}
+
+// JoinCounts returns how many times a join function has merged values for
+// each key on the chain of a context, for finding keys that merge
+// excessively. Keys that were never joined are omitted. Join and JoinLazy
+// count the joins they perform, but not those on the joined context.
+func JoinCounts(ctx context.Context) map[string]int {
+	learned, unlearned := keySets(ctx)
+	bkeys := append(learned, unlearned...)
+	counts := make(map[string]int)
+	for _, bkey := range bkeys {
+		if n := joinCount(ctx, bkey); n > 0 {
+			counts[string(bkey)] = n
+		}
+	}
+	return counts
+}
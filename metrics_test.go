@@ -0,0 +1,84 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func TestSetJoinMetrics(t *testing.T) {
+	var mu sync.Mutex
+	counts := map[string]int{}
+	SetJoinMetrics(func(key string) {
+		mu.Lock()
+		counts[key]++
+		mu.Unlock()
+	})
+	defer SetJoinMetrics(nil)
+
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithTTL(ctx, 3*time.Second)
+	ctx = WithTTL(ctx, 2*time.Second)
+	ctx = WithTTL(ctx, time.Second)
+	assert.Equal(t, 2, counts["ttl"], "the first hop has nothing to join")
+
+	branch := WithTTL(ctx, 500*time.Millisecond)
+	ctx = Join(ctx, branch)
+	assert.Equal(t, 4, counts["ttl"])
+	ctx = WithBaggage(ctx, "Shard", "a")
+	WithBaggage(ctx, "Shard", "b")
+	assert.Equal(t, map[string]int{"ttl": 4}, counts, "last value wins without a join")
+}
+
+func TestJoinCounts(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "ttl", joinTTL)
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithTTL(ctx, 4*time.Second)
+	ctx = WithBaggage(ctx, "Shard", "a")
+	assert.Equal(t, map[string]int{}, JoinCounts(ctx))
+
+	ctx = WithTTL(ctx, 3*time.Second)
+	ctx = WithTTL(ctx, 2*time.Second)
+	ctx = WithTTL(ctx, time.Second)
+	ctx = WithBaggage(ctx, "Shard", "b")
+	assert.Equal(t, map[string]int{"ttl": 3}, JoinCounts(ctx))
+
+	branch := WithReceipt(WithTTL(context.Background(), time.Second), "alice")
+	eager := Join(ctx, branch)
+	assert.Equal(t, map[string]int{"ttl": 4}, JoinCounts(eager), "receipts had nothing to join")
+	assert.Equal(t, map[string]int{"ttl": 4}, JoinCounts(JoinLazy(ctx, branch)))
+	assert.Equal(t, map[string]int{"ttl": 3}, JoinCounts(ctx), "the original context is unchanged")
+	assert.Equal(t, map[string]int{"ttl": 5, "receipts": 2}, JoinCounts(Join(WithReceipt(eager, "bob"), branch)))
+
+	joinedCtx := WithTTL(ctx, time.Second)
+	node, ok := joinedCtx.(*valueContext)
+	require.True(t, ok)
+	assert.Equal(t, ctx, node.Context, "counting a join adds no context")
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package natsctx carries Open Context baggage over NATS message headers.
+//
+// NATS headers have the same shape as HTTP headers, so baggage is written and
+// read exactly as by openctx.SerializeHeaders and openctx.DeserializeHeaders,
+// with the header prefix configured on the context, or the default prefix.
+package natsctx
+
+import (
+	"net/http"
+
+	"github.com/nats-io/nats.go"
+	"github.com/openctx/openctx-go"
+
+	"golang.org/x/net/context"
+)
+
+// ToNATSHeader returns the baggage carried by a context as NATS message
+// headers, one header per property.
+func ToNATSHeader(ctx context.Context) nats.Header {
+	h := http.Header{}
+	openctx.SerializeHeaders(ctx, h, "")
+	return nats.Header(h)
+}
+
+// FromNATSHeader returns a context carrying the baggage from NATS message
+// headers, joining each value with any prior value through the join functions
+// in context.
+func FromNATSHeader(ctx context.Context, h nats.Header) context.Context {
+	return openctx.DeserializeHeaders(ctx, http.Header(h), "")
+}
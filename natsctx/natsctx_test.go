@@ -0,0 +1,88 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package natsctx
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/openctx/openctx-go"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func joinReceipts(a, b string) string {
+	return a + ", " + b
+}
+
+func TestNATSHeaderRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "TTL", "1000")
+	ctx = openctx.WithBaggage(ctx, "Note", "a, b")
+	h := ToNATSHeader(ctx)
+	assert.Equal(t, "1000", h.Get("Ctx-Ttl"))
+	assert.Equal(t, "a%2C b", h.Get("Ctx-Note"))
+
+	out := FromNATSHeader(context.Background(), h)
+	assert.Equal(t, []string{"note", "ttl"}, openctx.Keys(out))
+	note, _ := openctx.Baggage(out, "note")
+	assert.Equal(t, "a, b", note)
+}
+
+func TestFromNATSHeaderJoins(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithJoin(ctx, "receipts", joinReceipts)
+	ctx = openctx.WithBaggage(ctx, "receipts", "alice")
+
+	h := nats.Header{}
+	h.Add("ctx-receipts", "bob")
+	h.Add("ctx-receipts", "charlie")
+	h.Add("unrelated", "value")
+	ctx = FromNATSHeader(ctx, h)
+	receipts, _ := openctx.Baggage(ctx, "receipts")
+	assert.Equal(t, "alice, bob, charlie", receipts)
+	assert.Equal(t, []string{"receipts"}, openctx.Keys(ctx))
+}
+
+func TestNATSHeaderPrefix(t *testing.T) {
+	ctx := openctx.WithHeaderPrefix(context.Background(), "baggage-")
+	ctx = openctx.WithBaggage(ctx, "Tenant", "acme")
+	h := ToNATSHeader(ctx)
+	assert.Equal(t, "acme", h.Get("Baggage-Tenant"))
+
+	out := FromNATSHeader(openctx.WithHeaderPrefix(context.Background(), "baggage-"), h)
+	tenant, _ := openctx.Baggage(out, "tenant")
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestNATSHeaderHopLimit(t *testing.T) {
+	ctx := openctx.WithHopLimit(context.Background(), "Debug", "verbose", 2)
+	ctx = openctx.WithBaggage(ctx, "Token", "secret")
+	ctx = openctx.WithEgressAllow(ctx, "Debug")
+	ctx = FromNATSHeader(context.Background(), ToNATSHeader(ctx))
+	assert.Equal(t, []string{"debug", "openctx.hops.debug"}, openctx.Keys(ctx))
+	hops, _ := openctx.HopsRemaining(ctx, "Debug")
+	assert.Equal(t, 1, hops)
+
+	ctx = FromNATSHeader(context.Background(), ToNATSHeader(ctx))
+	assert.Equal(t, []string{}, openctx.Keys(ctx))
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package openctxtest provides helpers for testing code that uses Open
+// Context, such as checks that a custom join function is safe for parallel
+// merges.
+package openctxtest
+
+// TestingT is the subset of *testing.T used to report violations.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type helper interface {
+	Helper()
+}
+
+// AssertJoinAssociative checks that a join function is associative over every
+// ordered choice of three samples, with repetition: joining a with b and then
+// c must equal joining a with the join of b and c. Baggage from parallel
+// branches converges on the same value regardless of how the merges are
+// grouped only if the join function is associative. It reports each
+// violation to t and returns whether there were none.
+func AssertJoinAssociative(t TestingT, join func(a, b string) string, samples ...string) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	ok := true
+	for _, a := range samples {
+		for _, b := range samples {
+			for _, c := range samples {
+				left := join(join(a, b), c)
+				right := join(a, join(b, c))
+				if left != right {
+					t.Errorf("join is not associative for %q, %q, %q: (a b) c = %q, a (b c) = %q", a, b, c, left, right)
+					ok = false
+				}
+			}
+		}
+	}
+	return ok
+}
+
+// AssertJoinCommutative checks that a join function gives the same result for
+// two values in either order. Baggage from parallel branches converges on the
+// same value regardless of the order in which they are joined only if the
+// join function is commutative. It reports a violation to t and returns
+// whether there was none.
+func AssertJoinCommutative(t TestingT, join func(a, b string) string, a, b string) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	ab, ba := join(a, b), join(b, a)
+	if ab != ba {
+		t.Errorf("join is not commutative for %q, %q: a b = %q, b a = %q", a, b, ab, ba)
+		return false
+	}
+	return true
+}
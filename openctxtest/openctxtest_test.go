@@ -0,0 +1,84 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctxtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openctx/openctx-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder collects the violations reported by an assertion.
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestBuiltInJoiners(t *testing.T) {
+	numbers := []string{"-3", "0", "9", "100"}
+	for name, join := range map[string]func(a, b string) string{
+		"JoinMin":        openctx.JoinMin,
+		"JoinMax":        openctx.JoinMax,
+		"JoinNumericMax": openctx.JoinNumericMax,
+		"JoinSum":        openctx.JoinSum,
+	} {
+		assert.True(t, AssertJoinAssociative(t, join, numbers...), name)
+		assert.True(t, AssertJoinCommutative(t, join, "9", "100"), name)
+	}
+
+	assert.True(t, AssertJoinAssociative(t, openctx.JoinOr, "true", "false"))
+	assert.True(t, AssertJoinCommutative(t, openctx.JoinOr, "true", "false"))
+	assert.True(t, AssertJoinAssociative(t, openctx.JoinProbabilityMax, "0", "0.25", "1"))
+	assert.True(t, AssertJoinCommutative(t, openctx.JoinProbabilityMax, "0.25", "1"))
+
+	union := openctx.JoinUnionFold(", ")
+	assert.True(t, AssertJoinAssociative(t, union, "alice", "bob", "alice, carol"))
+	assert.True(t, AssertJoinCommutative(t, union, "alice, carol", "bob"))
+}
+
+func TestViolations(t *testing.T) {
+	last := func(a, b string) string { return b }
+	concat := func(a, b string) string { return a + b }
+	average := func(a, b string) string {
+		var x, y float64
+		fmt.Sscan(a, &x)
+		fmt.Sscan(b, &y)
+		return fmt.Sprint((x + y) / 2)
+	}
+
+	r := &recorder{}
+	assert.False(t, AssertJoinCommutative(r, last, "a", "b"))
+	assert.Equal(t, []string{`join is not commutative for "a", "b": a b = "b", b a = "a"`}, r.errors)
+
+	r = &recorder{}
+	assert.True(t, AssertJoinAssociative(r, concat, "a", "b"), "concatenation is associative")
+	assert.False(t, AssertJoinCommutative(r, concat, "a", "b"))
+	assert.Len(t, r.errors, 1)
+
+	r = &recorder{}
+	assert.False(t, AssertJoinAssociative(r, average, "0", "4"))
+	assert.Contains(t, r.errors, `join is not associative for "0", "0", "4": (a b) c = "2", a (b c) = "1"`)
+}
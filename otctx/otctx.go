@@ -0,0 +1,52 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package otctx bridges OpenTracing span baggage and Open Context baggage.
+// It lives in its own package so that only users of OpenTracing take on the
+// dependency.
+package otctx
+
+import (
+	"github.com/openctx/openctx-go"
+	opentracing "github.com/opentracing/opentracing-go"
+
+	"golang.org/x/net/context"
+)
+
+// FromSpanContext imports every baggage item carried by a span context into
+// the returned context, joining with any prior values through WithBaggage.
+// Values whose hop limit is used up are dropped.
+func FromSpanContext(ctx context.Context, sc opentracing.SpanContext) context.Context {
+	sc.ForeachBaggageItem(func(key, value string) bool {
+		ctx = openctx.WithBaggage(ctx, key, value)
+		return true
+	})
+	return openctx.DropExhausted(ctx)
+}
+
+// ToSpanContext copies the baggage carried by a context onto a span as
+// baggage items, as written by openctx.OutboundEntries, and returns the
+// span's resulting span context.
+func ToSpanContext(ctx context.Context, span opentracing.Span) opentracing.SpanContext {
+	for _, entry := range openctx.OutboundEntries(ctx) {
+		span.SetBaggageItem(entry.Key, entry.Value)
+	}
+	return span.Context()
+}
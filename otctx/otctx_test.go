@@ -0,0 +1,92 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otctx
+
+import (
+	"testing"
+
+	"github.com/openctx/openctx-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestFromSpanContext(t *testing.T) {
+	span := mocktracer.New().StartSpan("inbound")
+	span.SetBaggageItem("receipts", "alice")
+	span.SetBaggageItem("ttl", "1000")
+
+	ctx := FromSpanContext(context.Background(), span.Context())
+	receipts, ok := openctx.Baggage(ctx, "receipts")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", receipts)
+	ttl, ok := openctx.Baggage(ctx, "ttl")
+	assert.True(t, ok)
+	assert.Equal(t, "1000", ttl)
+}
+
+func TestFromSpanContextJoins(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithJoin(ctx, "receipts", func(a, b string) string {
+		return a + ", " + b
+	})
+	ctx = openctx.WithBaggage(ctx, "receipts", "alice")
+
+	span := mocktracer.New().StartSpan("inbound")
+	span.SetBaggageItem("receipts", "bob")
+
+	ctx = FromSpanContext(ctx, span.Context())
+	receipts, _ := openctx.Baggage(ctx, "receipts")
+	assert.Equal(t, "alice, bob", receipts)
+}
+
+func TestToSpanContext(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "Receipts", "alice")
+	ctx = openctx.WithBaggage(ctx, "TTL", "1000")
+
+	span := mocktracer.New().StartSpan("outbound").(*mocktracer.MockSpan)
+	sc := ToSpanContext(ctx, span)
+	assert.Equal(t, "alice", span.BaggageItem("receipts"))
+	assert.Equal(t, "1000", span.BaggageItem("ttl"))
+
+	items := map[string]string{}
+	sc.ForeachBaggageItem(func(key, value string) bool {
+		items[key] = value
+		return true
+	})
+	assert.Equal(t, map[string]string{"receipts": "alice", "ttl": "1000"}, items)
+}
+
+func TestSpanContextHopLimit(t *testing.T) {
+	ctx := openctx.WithHopLimit(context.Background(), "Debug", "verbose", 2)
+	ctx = openctx.WithBaggage(ctx, "Token", "secret")
+	ctx = openctx.WithEgressAllow(ctx, "Debug")
+	tracer := mocktracer.New()
+	ctx = FromSpanContext(context.Background(), ToSpanContext(ctx, tracer.StartSpan("outbound")))
+	assert.Equal(t, []string{"debug", "openctx.hops.debug"}, openctx.Keys(ctx))
+	hops, _ := openctx.HopsRemaining(ctx, "Debug")
+	assert.Equal(t, 1, hops)
+
+	ctx = FromSpanContext(context.Background(), ToSpanContext(ctx, tracer.StartSpan("outbound")))
+	assert.Equal(t, []string{}, openctx.Keys(ctx))
+}
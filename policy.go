@@ -0,0 +1,97 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// A JoinPolicy decides which join function applies when both contexts given
+// to JoinWithPolicy have a different join function for the same key.
+type JoinPolicy int
+
+const (
+	// PreferBase keeps the join function of the base context, as
+	// JoinWithFuncs does.
+	PreferBase JoinPolicy = iota
+	// PreferOther takes the join function of the other context.
+	PreferOther
+	// JoinPolicyError refuses to join the contexts.
+	JoinPolicyError
+)
+
+// JoinWithPolicy joins two contexts like JoinWithFuncs, carrying over the join
+// functions of that context that this context lacks, and resolving keys for
+// which both have different join functions by the policy. Join functions are
+// the same if both were registered under the same name with RegisterJoin.
+// Otherwise, since functions cannot be compared, they are the same only if
+// both contexts found them through the same introduction, such as a WithJoin
+// call on a context they share; two closures from one factory, like
+// JoinUnion(",") and JoinUnion(";"), are different. With the JoinPolicyError
+// policy, if any key has different join functions, this context is returned
+// unchanged with an error naming the keys.
+func JoinWithPolicy(this, that context.Context, policy JoinPolicy) (context.Context, error) {
+	var conflicts []string
+	merged := this
+	for _, bkey := range learnedKeys() {
+		jkey := joinKey(bkey)
+		other, _ := that.Value(jkey).(func(a, b string) string)
+		if other == nil {
+			continue
+		}
+		base, _ := this.Value(jkey).(func(a, b string) string)
+		if base != nil {
+			if sameJoin(this, that, bkey) {
+				continue
+			}
+			conflicts = append(conflicts, string(bkey))
+			if policy != PreferOther {
+				continue
+			}
+		}
+		merged = &joinContext{Context: merged, bkey: bkey, join: other, ident: that.Value(joinIdentKey(bkey))}
+		name, _ := that.Value(joinNameKey(bkey)).(string)
+		if name != "" || merged.Value(joinNameKey(bkey)) != nil {
+			merged = context.WithValue(merged, joinNameKey(bkey), name)
+		}
+	}
+	if policy == JoinPolicyError && len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return this, fmt.Errorf("openctx: conflicting join functions for keys: %s", strings.Join(conflicts, ", "))
+	}
+	return Join(merged, that), nil
+}
+
+// sameJoin returns whether the join functions for a key in two contexts are
+// the same, by name if both contexts registered one, or else by introduction.
+func sameJoin(this, that context.Context, bkey baggageKey) bool {
+	aname, _ := this.Value(joinNameKey(bkey)).(string)
+	bname, _ := that.Value(joinNameKey(bkey)).(string)
+	if aname != "" && bname != "" {
+		return aname == bname
+	}
+	ident := this.Value(joinIdentKey(bkey))
+	return ident != nil && ident == that.Value(joinIdentKey(bkey))
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func policyContexts() (this, that context.Context) {
+	this = WithJoin(context.Background(), "Version", JoinMin)
+	this = WithBaggage(this, "Version", "5")
+	that = WithJoin(context.Background(), "Version", JoinMax)
+	that = WithJoin(that, "Receipts", joinReceipts)
+	that = WithBaggage(that, "Version", "7")
+	return this, that
+}
+
+func TestJoinWithPolicyPreferBase(t *testing.T) {
+	this, that := policyContexts()
+	ctx, err := JoinWithPolicy(this, that, PreferBase)
+	require.NoError(t, err)
+	version, _ := Baggage(ctx, "Version")
+	assert.Equal(t, "5", version)
+	assert.Equal(t, []string{"receipts", "version"}, JoinKeys(ctx), "carries over missing join functions")
+}
+
+func TestJoinWithPolicyPreferOther(t *testing.T) {
+	this, that := policyContexts()
+	ctx, err := JoinWithPolicy(this, that, PreferOther)
+	require.NoError(t, err)
+	version, _ := Baggage(ctx, "Version")
+	assert.Equal(t, "7", version)
+	ctx = WithBaggage(ctx, "Version", "6")
+	version, _ = Baggage(ctx, "Version")
+	assert.Equal(t, "7", version, "the other join function stays in place")
+}
+
+func TestJoinWithPolicyError(t *testing.T) {
+	this, that := policyContexts()
+	ctx, err := JoinWithPolicy(this, that, JoinPolicyError)
+	assert.EqualError(t, err, "openctx: conflicting join functions for keys: version")
+	assert.Equal(t, this, ctx)
+
+	base := WithJoin(context.Background(), "Version", JoinMin)
+	this = WithBaggage(base, "Version", "5")
+	that = WithBaggage(base, "Version", "7")
+	ctx, err = JoinWithPolicy(this, that, JoinPolicyError)
+	require.NoError(t, err, "a join function introduced on a shared context does not conflict")
+	version, _ := Baggage(ctx, "Version")
+	assert.Equal(t, "5", version)
+
+	_, err = JoinWithPolicy(this, WithJoin(that, "Version", JoinMin), JoinPolicyError)
+	assert.Error(t, err, "separate introductions of unnamed functions conflict")
+}
+
+func TestJoinWithPolicyClosures(t *testing.T) {
+	this := WithJoin(context.Background(), "Tags", JoinUnion(", "))
+	this = WithBaggage(this, "Tags", "a")
+	that := WithJoin(context.Background(), "Tags", JoinUnion(";"))
+	that = WithBaggage(that, "Tags", "b")
+	_, err := JoinWithPolicy(this, that, JoinPolicyError)
+	assert.EqualError(t, err, "openctx: conflicting join functions for keys: tags")
+
+	ctx, err := JoinWithPolicy(this, that, PreferOther)
+	require.NoError(t, err)
+	tags, _ := Baggage(ctx, "Tags")
+	assert.Equal(t, "a;b", tags, "the other closure is taken")
+	_, err = JoinWithPolicy(ctx, that, JoinPolicyError)
+	assert.NoError(t, err, "the taken function keeps its introduction")
+}
+
+func TestJoinWithPolicyNames(t *testing.T) {
+	this := RegisterJoin(context.Background(), "Tags", "union", JoinUnion(","))
+	that := RegisterJoin(context.Background(), "Tags", "union-semicolon", JoinUnion(";"))
+	_, err := JoinWithPolicy(this, that, JoinPolicyError)
+	assert.Error(t, err, "different names conflict even for the same function")
+
+	that = RegisterJoin(context.Background(), "Tags", "union", JoinUnion(","))
+	_, err = JoinWithPolicy(this, that, JoinPolicyError)
+	assert.NoError(t, err)
+
+	that = RegisterJoin(context.Background(), "Tags", "union-semicolon", JoinUnion(";"))
+	ctx, err := JoinWithPolicy(this, that, PreferOther)
+	require.NoError(t, err)
+	assert.Equal(t, JoinFingerprint(that), JoinFingerprint(ctx))
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// A Propagator writes the baggage carried by a context onto a carrier, and
+// reads it back, in one wire format. The carrier types a propagator accepts
+// depend on its format.
+type Propagator interface {
+	// Inject writes the baggage carried by a context onto a carrier.
+	Inject(ctx context.Context, carrier interface{}) error
+	// Extract returns a context carrying the baggage from a carrier, joining
+	// each value with any prior value through the join functions in context.
+	Extract(ctx context.Context, carrier interface{}) (context.Context, error)
+}
+
+var (
+	// ErrUnsupportedCarrier is returned by a Propagator given a carrier of a
+	// type it does not accept.
+	ErrUnsupportedCarrier = errors.New("openctx: unsupported carrier")
+
+	// ErrNoBaggage is returned by Extract when a carrier holds no baggage in
+	// the propagator's format.
+	ErrNoBaggage = errors.New("openctx: no baggage in carrier")
+)
+
+// HTTPPropagator carries baggage on an http.Header with SerializeHeaders and
+// DeserializeHeaders, under a prefix. If Prefix is empty, the prefix
+// configured with WithHeaderPrefix applies, or DefaultHeaderPrefix.
+type HTTPPropagator struct {
+	Prefix string
+}
+
+// Inject writes baggage onto an http.Header carrier.
+func (p HTTPPropagator) Inject(ctx context.Context, carrier interface{}) error {
+	h, ok := carrier.(http.Header)
+	if !ok {
+		return ErrUnsupportedCarrier
+	}
+	SerializeHeaders(ctx, h, p.Prefix)
+	return nil
+}
+
+// Extract reads baggage from an http.Header carrier.
+func (p HTTPPropagator) Extract(ctx context.Context, carrier interface{}) (context.Context, error) {
+	h, ok := carrier.(http.Header)
+	if !ok {
+		return ctx, ErrUnsupportedCarrier
+	}
+	prefix := headerPrefix(ctx, p.Prefix)
+	for name := range h {
+		if _, ok := headerKey(name, prefix); ok {
+			return DeserializeHeaders(ctx, h, prefix), nil
+		}
+	}
+	return ctx, ErrNoBaggage
+}
+
+// W3CBaggageHeader is the HTTP header that carries baggage in the W3C baggage
+// format.
+const W3CBaggageHeader = "Baggage"
+
+// W3CPropagator carries baggage on an http.Header in the W3C baggage format,
+// with MarshalW3CBaggage and UnmarshalW3CBaggage.
+type W3CPropagator struct{}
+
+// Inject writes baggage onto an http.Header carrier.
+func (W3CPropagator) Inject(ctx context.Context, carrier interface{}) error {
+	h, ok := carrier.(http.Header)
+	if !ok {
+		return ErrUnsupportedCarrier
+	}
+	if s := MarshalW3CBaggage(ctx); s != "" {
+		h.Set(W3CBaggageHeader, s)
+	}
+	return nil
+}
+
+// Extract reads baggage from an http.Header carrier.
+func (W3CPropagator) Extract(ctx context.Context, carrier interface{}) (context.Context, error) {
+	h, ok := carrier.(http.Header)
+	if !ok {
+		return ctx, ErrUnsupportedCarrier
+	}
+	s := h.Get(W3CBaggageHeader)
+	if s == "" {
+		return ctx, ErrNoBaggage
+	}
+	return UnmarshalW3CBaggage(ctx, s)
+}
+
+// JSONPropagator carries baggage as a JSON object of string values. It
+// injects onto an io.Writer, and extracts from a []byte or an io.Reader.
+type JSONPropagator struct{}
+
+// Inject writes baggage onto an io.Writer carrier.
+func (JSONPropagator) Inject(ctx context.Context, carrier interface{}) error {
+	w, ok := carrier.(io.Writer)
+	if !ok {
+		return ErrUnsupportedCarrier
+	}
+	baggage := make(map[string]string)
+	for _, entry := range egressEntries(ctx) {
+		baggage[entry.Key] = entry.Value
+	}
+	b, err := json.Marshal(baggage)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Extract reads baggage from a []byte or io.Reader carrier.
+func (JSONPropagator) Extract(ctx context.Context, carrier interface{}) (context.Context, error) {
+	b, err := carrierBytes(carrier)
+	if err != nil {
+		return ctx, err
+	}
+	var baggage map[string]string
+	if err := json.Unmarshal(b, &baggage); err != nil {
+		return ctx, err
+	}
+	keys := make([]string, 0, len(baggage))
+	for key, value := range baggage {
+		ctx = WithBaggage(ctx, key, value)
+		keys = append(keys, key)
+	}
+	return dropExhausted(ctx, keys), nil
+}
+
+// BinaryPropagator carries baggage in the length-prefixed format of Encode and
+// Decode. It injects onto an io.Writer, and extracts from a []byte, which it
+// must consume entirely, or an io.Reader.
+type BinaryPropagator struct{}
+
+// Inject writes baggage onto an io.Writer carrier.
+func (BinaryPropagator) Inject(ctx context.Context, carrier interface{}) error {
+	w, ok := carrier.(io.Writer)
+	if !ok {
+		return ErrUnsupportedCarrier
+	}
+	return Encode(ctx, w)
+}
+
+// Extract reads baggage from a []byte or io.Reader carrier.
+func (BinaryPropagator) Extract(ctx context.Context, carrier interface{}) (context.Context, error) {
+	switch carrier := carrier.(type) {
+	case []byte:
+		if len(carrier) == 0 {
+			return ctx, ErrNoBaggage
+		}
+		r := bytes.NewReader(carrier)
+		out, err := Decode(ctx, r)
+		if err != nil {
+			return ctx, err
+		}
+		if r.Len() > 0 {
+			return ctx, errors.New("openctx: trailing bytes after encoded baggage")
+		}
+		return out, nil
+	case io.Reader:
+		return Decode(ctx, carrier)
+	}
+	return ctx, ErrUnsupportedCarrier
+}
+
+// carrierBytes returns the contents of a []byte or io.Reader carrier, or
+// ErrNoBaggage if it is empty.
+func carrierBytes(carrier interface{}) ([]byte, error) {
+	var b []byte
+	switch carrier := carrier.(type) {
+	case []byte:
+		b = carrier
+	case io.Reader:
+		var err error
+		if b, err = ioutil.ReadAll(carrier); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedCarrier
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, ErrNoBaggage
+	}
+	return b, nil
+}
+
+// MultiPropagator injects with one propagator, and extracts with the first of
+// several propagators that succeeds, so that a service can accept every
+// format its peers send while sending its preferred format. Extractors that
+// read from an io.Reader consume it, so a carrier shared by several stream
+// formats should be a []byte.
+type MultiPropagator struct {
+	Injector   Propagator
+	Extractors []Propagator
+}
+
+// Inject writes baggage onto a carrier with the injector.
+func (p MultiPropagator) Inject(ctx context.Context, carrier interface{}) error {
+	return p.Injector.Inject(ctx, carrier)
+}
+
+// Extract tries each extractor in order, returning the context from the first
+// that succeeds. If none succeeds, it returns the context unchanged and the
+// error of the first extractor that found but could not read baggage, or else
+// ErrNoBaggage.
+func (p MultiPropagator) Extract(ctx context.Context, carrier interface{}) (context.Context, error) {
+	var firstErr error
+	for _, extractor := range p.Extractors {
+		out, err := extractor.Extract(ctx, carrier)
+		if err == nil {
+			return out, nil
+		}
+		if firstErr == nil && err != ErrNoBaggage && err != ErrUnsupportedCarrier {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = ErrNoBaggage
+	}
+	return ctx, firstErr
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func propagatorBaggage() context.Context {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Note", "a, b; c=d 100%")
+	return ctx
+}
+
+func assertPropagatedBaggage(t *testing.T, ctx context.Context) {
+	assert.Equal(t, []string{"note", "tenant"}, Keys(ctx))
+	note, _ := Baggage(ctx, "note")
+	assert.Equal(t, "a, b; c=d 100%", note)
+	tenant, _ := Baggage(ctx, "tenant")
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestHeaderPropagators(t *testing.T) {
+	for _, p := range []Propagator{HTTPPropagator{}, HTTPPropagator{Prefix: "x-baggage-"}, W3CPropagator{}} {
+		h := http.Header{}
+		require.NoError(t, p.Inject(propagatorBaggage(), h))
+		ctx, err := p.Extract(context.Background(), h)
+		require.NoError(t, err)
+		assertPropagatedBaggage(t, ctx)
+
+		_, err = p.Extract(context.Background(), http.Header{})
+		assert.Equal(t, ErrNoBaggage, err)
+		assert.Equal(t, ErrUnsupportedCarrier, p.Inject(propagatorBaggage(), &bytes.Buffer{}))
+	}
+}
+
+func TestStreamPropagators(t *testing.T) {
+	for _, p := range []Propagator{JSONPropagator{}, BinaryPropagator{}} {
+		var buf bytes.Buffer
+		require.NoError(t, p.Inject(propagatorBaggage(), &buf))
+
+		ctx, err := p.Extract(context.Background(), buf.Bytes())
+		require.NoError(t, err)
+		assertPropagatedBaggage(t, ctx)
+
+		ctx, err = p.Extract(context.Background(), bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		assertPropagatedBaggage(t, ctx)
+
+		_, err = p.Extract(context.Background(), []byte{})
+		assert.Equal(t, ErrNoBaggage, err)
+		_, err = p.Extract(context.Background(), http.Header{})
+		assert.Equal(t, ErrUnsupportedCarrier, err)
+	}
+}
+
+func TestPropagatorsEgress(t *testing.T) {
+	ctx := WithBaggage(propagatorBaggage(), "Token", "secret")
+	ctx = WithHopLimit(ctx, "Region", "us-east", 2)
+	ctx = WithHopLimit(ctx, "Shard", "a", 1)
+	ctx = WithEgressAllow(ctx, "Tenant", "Region", "Shard")
+	for _, p := range []Propagator{HTTPPropagator{}, W3CPropagator{}} {
+		h := http.Header{}
+		require.NoError(t, p.Inject(ctx, h))
+		out, err := p.Extract(context.Background(), h)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"openctx.hops.region", "region", "tenant"}, Keys(out), "%T", p)
+		hops, _ := HopsRemaining(out, "Region")
+		assert.Equal(t, 1, hops, "%T", p)
+	}
+	for _, p := range []Propagator{JSONPropagator{}, BinaryPropagator{}} {
+		var buf bytes.Buffer
+		require.NoError(t, p.Inject(ctx, &buf))
+		out, err := p.Extract(context.Background(), buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"openctx.hops.region", "region", "tenant"}, Keys(out), "%T", p)
+		hops, _ := HopsRemaining(out, "Region")
+		assert.Equal(t, 1, hops, "%T", p)
+	}
+}
+
+func TestJSONPropagatorJoins(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+	ctx, err := JSONPropagator{}.Extract(ctx, strings.NewReader(`{"receipts": "bob"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(ctx))
+
+	_, err = JSONPropagator{}.Extract(ctx, []byte(`{"receipts": 1}`))
+	assert.Error(t, err)
+}
+
+func TestMultiPropagatorHeaders(t *testing.T) {
+	p := MultiPropagator{
+		Injector:   W3CPropagator{},
+		Extractors: []Propagator{W3CPropagator{}, HTTPPropagator{}},
+	}
+	h := http.Header{}
+	require.NoError(t, p.Inject(propagatorBaggage(), h))
+	assert.Equal(t, []string{W3CBaggageHeader}, headerNames(h))
+
+	ctx, err := p.Extract(context.Background(), h)
+	require.NoError(t, err)
+	assertPropagatedBaggage(t, ctx)
+
+	h = http.Header{}
+	require.NoError(t, HTTPPropagator{}.Inject(propagatorBaggage(), h))
+	ctx, err = p.Extract(context.Background(), h)
+	require.NoError(t, err, "falls back to prefixed headers")
+	assertPropagatedBaggage(t, ctx)
+
+	_, err = p.Extract(context.Background(), http.Header{})
+	assert.Equal(t, ErrNoBaggage, err)
+}
+
+func TestMultiPropagatorStreams(t *testing.T) {
+	p := MultiPropagator{
+		Injector:   BinaryPropagator{},
+		Extractors: []Propagator{JSONPropagator{}, BinaryPropagator{}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, p.Inject(propagatorBaggage(), &buf))
+	ctx, err := p.Extract(context.Background(), buf.Bytes())
+	require.NoError(t, err, "falls back to binary")
+	assertPropagatedBaggage(t, ctx)
+
+	ctx, err = p.Extract(context.Background(), []byte(`{"tenant": "acme"`))
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrNoBaggage, err)
+	assert.False(t, HasBaggage(ctx))
+}
+
+func headerNames(h http.Header) []string {
+	var names []string
+	for name := range h {
+		names = append(names, name)
+	}
+	return names
+}
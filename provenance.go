@@ -0,0 +1,87 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"golang.org/x/net/context"
+)
+
+// The provenance of a baggage value is carried on a context map by a
+// provenance key.
+type provenanceKey string
+
+// A provenance records the source of a baggage value along with the value
+// itself, so that the record no longer applies once the value is replaced.
+type provenance struct {
+	value  string
+	source string
+}
+
+// WithBaggageFrom adds a baggage value like WithBaggage and records the
+// source, typically a service name, that produced the resulting value. If a
+// join function keeps the prior value, its provenance is kept as well. The
+// source is not part of the baggage value and is not serialized.
+func WithBaggageFrom(ctx context.Context, key, value, source string) context.Context {
+	bkey := baggageKey(canonicalKey(key))
+	prior, hadPrior := lookup(ctx, bkey)
+	ctx = withBaggage(ctx, bkey, value)
+	value, _ = lookup(ctx, bkey)
+	if hadPrior && value == prior {
+		return ctx
+	}
+	return context.WithValue(ctx, provenanceKey(bkey), provenance{value, source})
+}
+
+// Provenance returns the source recorded by WithBaggageFrom for the current
+// value of a baggage key. There is no provenance once the value is replaced by
+// a write that does not record a source.
+func Provenance(ctx context.Context, key string) (source string, ok bool) {
+	bkey := baggageKey(canonicalKey(key))
+	return lookupProvenance(ctx, bkey)
+}
+
+func lookupProvenance(ctx context.Context, bkey baggageKey) (source string, ok bool) {
+	p, ok := ctx.Value(provenanceKey(bkey)).(provenance)
+	if !ok {
+		return "", false
+	}
+	value, ok := lookup(ctx, bkey)
+	if !ok || value != p.value {
+		return "", false
+	}
+	return p.source, true
+}
+
+// joinProvenance carries the provenance of a value joined from that context
+// when its value won the join.
+func joinProvenance(this, that context.Context, bkey baggageKey) context.Context {
+	p, ok := that.Value(provenanceKey(bkey)).(provenance)
+	if !ok {
+		return this
+	}
+	if value, _ := lookup(this, bkey); value != p.value {
+		return this
+	}
+	if _, ok := lookupProvenance(that, bkey); !ok {
+		return this
+	}
+	return context.WithValue(this, provenanceKey(bkey), p)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestProvenance(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggageFrom(ctx, "Shard", "a", "alice")
+	source, ok := Provenance(ctx, "Shard")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", source)
+
+	ctx = WithBaggageFrom(ctx, "Shard", "b", "bob")
+	source, _ = Provenance(ctx, "Shard")
+	assert.Equal(t, "bob", source)
+
+	ctx = WithBaggage(ctx, "Shard", "c")
+	_, ok = Provenance(ctx, "Shard")
+	assert.False(t, ok, "provenance does not apply to a later value")
+}
+
+func TestProvenanceFollowsJoinWinner(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "Deadline-Ms", JoinMin)
+	ctx = WithBaggageFrom(ctx, "Deadline-Ms", "500", "charlie")
+
+	ctxA := WithBaggageFrom(ctx, "Deadline-Ms", "100", "alice")
+	ctxB := WithBaggageFrom(ctx, "Deadline-Ms", "900", "bob")
+
+	joined := Join(Join(ctx, ctxA), ctxB)
+	deadline, _ := Baggage(joined, "Deadline-Ms")
+	assert.Equal(t, "100", deadline)
+	source, ok := Provenance(joined, "Deadline-Ms")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", source)
+
+	joined = Join(ctx, ctxB)
+	source, _ = Provenance(joined, "Deadline-Ms")
+	assert.Equal(t, "charlie", source, "the base value won")
+}
+
+func TestProvenanceNotSerialized(t *testing.T) {
+	ctx := WithBaggageFrom(context.Background(), "Shard", "a", "alice")
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	assert.Equal(t, http.Header{"Ctx-Shard": {"a"}}, h)
+}
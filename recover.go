@@ -0,0 +1,66 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Join panic recovery is off by default, so that a broken join function fails
+// fast.
+var joinRecovery atomicFlag
+
+// The join panic hook is stored atomically, so that it may be read by any
+// number of goroutines.
+var joinPanicHook atomic.Value
+
+// SetJoinPanicRecovery turns join panic recovery on or off. With recovery on,
+// a join function that panics is treated as if the key had no join function,
+// so the later value wins, and the panic is reported to the hook installed
+// with SetJoinPanicHook.
+func SetJoinPanicRecovery(on bool) {
+	joinRecovery.set(on)
+}
+
+// SetJoinPanicHook installs a hook that is called with the key and an error
+// describing the panic each time join panic recovery recovers from a join
+// function. Setting nil removes the hook.
+func SetJoinPanicHook(fn func(key string, err error)) {
+	joinPanicHook.Store(fn)
+}
+
+// callJoin merges two baggage values with a join function, recovering from a
+// panic if join panic recovery is on.
+func callJoin(bkey baggageKey, join func(a, b string) string, a, b string) (value string) {
+	if !joinRecovery.on() {
+		return join(a, b)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			value = b
+			if fn, _ := joinPanicHook.Load().(func(key string, err error)); fn != nil {
+				fn(string(bkey), fmt.Errorf("openctx: join function for %q panicked: %v", string(bkey), r))
+			}
+		}
+	}()
+	return join(a, b)
+}
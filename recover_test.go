@@ -0,0 +1,66 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func joinPanics(a, b string) string {
+	var parts []string
+	return parts[1]
+}
+
+func TestJoinPanicRecovery(t *testing.T) {
+	var keys []string
+	var errs []error
+	SetJoinPanicRecovery(true)
+	SetJoinPanicHook(func(key string, err error) {
+		keys = append(keys, key)
+		errs = append(errs, err)
+	})
+	defer SetJoinPanicRecovery(false)
+	defer SetJoinPanicHook(nil)
+
+	ctx := WithJoin(context.Background(), "Shard", joinPanics)
+	ctx = WithBaggage(ctx, "Shard", "a")
+	assert.NotPanics(t, func() {
+		ctx = WithBaggage(ctx, "Shard", "b")
+	})
+	shard, _ := Baggage(ctx, "Shard")
+	assert.Equal(t, "b", shard, "the later value wins")
+	assert.Equal(t, []string{"shard"}, keys)
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "index out of range")
+	}
+}
+
+func TestJoinPanicFailsFast(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Shard", joinPanics)
+	ctx = WithBaggage(ctx, "Shard", "a")
+	assert.Panics(t, func() {
+		WithBaggage(ctx, "Shard", "b")
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Redacted replaces the values of redacted baggage properties.
+const Redacted = "[REDACTED]"
+
+// Key names containing any of these words are considered sensitive by
+// RedactSensitive.
+var sensitiveWords = []string{"authorization", "token", "secret", "password"}
+
+// RedactSensitive is the default redactor for RedactedMap. It redacts keys
+// that name credentials, like "authorization" or "auth-token".
+func RedactSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range sensitiveWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactedMap returns the baggage carried by a context as a map suitable for
+// logging, with the values of keys selected by the redact function replaced
+// by Redacted. If redact is nil, RedactSensitive is used.
+func RedactedMap(ctx context.Context, redact func(key string) bool) map[string]string {
+	if redact == nil {
+		redact = RedactSensitive
+	}
+	keys := Keys(ctx)
+	baggage := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if redact(key) {
+			baggage[key] = Redacted
+			continue
+		}
+		baggage[key], _ = Baggage(ctx, key)
+	}
+	return baggage
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// SampledKey is the baggage key for the sampling decision recorded by
+// WithSamplingDecision.
+const SampledKey = "sampled"
+
+// WithSamplingDecision records whether a trace is sampled, so that every
+// service in the trace makes the same decision. Decisions are merged by
+// JoinOr: once any branch decides to sample, the trace stays sampled.
+func WithSamplingDecision(ctx context.Context, sampled bool) context.Context {
+	return withOwnJoin(ctx, SampledKey, strconv.FormatBool(sampled), JoinOr)
+}
+
+// SamplingDecision returns the sampling decision recorded by
+// WithSamplingDecision, and whether there is one.
+func SamplingDecision(ctx context.Context) (sampled bool, ok bool) {
+	value, ok := Baggage(ctx, SampledKey)
+	if !ok {
+		return false, false
+	}
+	sampled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false
+	}
+	return sampled, true
+}
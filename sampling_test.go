@@ -0,0 +1,57 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestSamplingDecision(t *testing.T) {
+	_, ok := SamplingDecision(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithSamplingDecision(context.Background(), false)
+	sampled, ok := SamplingDecision(ctx)
+	assert.True(t, ok)
+	assert.False(t, sampled)
+
+	a := WithBaggage(ctx, "Shard", "a")
+	b := WithSamplingDecision(ctx, true)
+	c := WithSamplingDecision(ctx, false)
+	merged := Join(Join(Join(ctx, a), b), c)
+	sampled, _ = SamplingDecision(merged)
+	assert.True(t, sampled, "once sampled, stays sampled")
+
+	merged = WithSamplingDecision(merged, false)
+	merged = Join(merged, c)
+	sampled, _ = SamplingDecision(merged)
+	assert.True(t, sampled)
+
+	sampled, _ = SamplingDecision(Join(ctx, c))
+	assert.False(t, sampled)
+
+	_, ok = SamplingDecision(WithBaggage(context.Background(), "Sampled", "maybe"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"errors"
+	"sync"
+)
+
+// The schema is the set of baggage keys a process expects to write. It is
+// registered globally, typically at init time, and read by any number of
+// goroutines.
+var (
+	schemaMu sync.RWMutex
+	schema   map[baggageKey]struct{}
+)
+
+// Strict schema mode is off by default.
+var strictSchema atomicFlag
+
+// ErrNotInSchema is returned by TryWithBaggage in strict schema mode for a key
+// that has not been registered with RegisterSchema.
+var ErrNotInSchema = errors.New("openctx: baggage key is not in the registered schema")
+
+// RegisterSchema adds keys to the schema of baggage keys this process expects
+// to write. The schema only has an effect in strict schema mode.
+func RegisterSchema(keys ...string) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if schema == nil {
+		schema = make(map[baggageKey]struct{}, len(keys))
+	}
+	for _, key := range keys {
+		schema[baggageKey(canonicalKey(key))] = struct{}{}
+	}
+}
+
+// StrictSchema turns strict schema mode on or off. In strict schema mode,
+// TryWithBaggage returns ErrNotInSchema for a key that has not been
+// registered with RegisterSchema, catching misspelled keys during
+// development. Other writes are unaffected. Strict schema mode is off by
+// default.
+func StrictSchema(on bool) {
+	strictSchema.set(on)
+}
+
+// checkSchema returns ErrNotInSchema in strict schema mode for a key that is
+// not in the schema.
+func checkSchema(bkey baggageKey) error {
+	if !strictSchema.on() {
+		return nil
+	}
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	if _, ok := schema[bkey]; !ok {
+		return ErrNotInSchema
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestStrictSchema(t *testing.T) {
+	RegisterSchema("Receipts", "TTL")
+	StrictSchema(true)
+	defer StrictSchema(false)
+
+	ctx, err := TryWithBaggage(context.Background(), "receipts", "alice")
+	assert.NoError(t, err)
+	receipts, _ := Baggage(ctx, "Receipts")
+	assert.Equal(t, "alice", receipts)
+
+	out, err := TryWithBaggage(ctx, "Recepts", "mallory")
+	assert.Equal(t, ErrNotInSchema, err)
+	assert.Equal(t, ctx, out)
+	_, ok := Baggage(out, "Recepts")
+	assert.False(t, ok)
+}
+
+func TestSchemaNotStrict(t *testing.T) {
+	RegisterSchema("Receipts")
+	ctx, err := TryWithBaggage(context.Background(), "Unregistered", "value")
+	assert.NoError(t, err)
+	assert.True(t, Has(ctx, "Unregistered"))
+}
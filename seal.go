@@ -0,0 +1,96 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Sealed keys are marked on a context map by a seal key.
+type sealKey string
+
+// ErrSealed is returned by TryWithBaggage for a key that has been sealed.
+var ErrSealed = errors.New("openctx: baggage key is sealed")
+
+// Seal returns a context on which the given keys can no longer be changed.
+// Subsequent writes to sealed keys, including joins, leave the prior value in
+// place. This protects values assigned at ingress, like a request ID, from
+// downstream code.
+func Seal(ctx context.Context, keys ...string) context.Context {
+	for _, key := range keys {
+		ctx = context.WithValue(ctx, sealKey(canonicalKey(key)), true)
+	}
+	return ctx
+}
+
+// Sealed returns whether a key has been sealed on a context.
+func Sealed(ctx context.Context, key string) bool {
+	return isSealed(ctx, baggageKey(canonicalKey(key)))
+}
+
+func isSealed(ctx context.Context, bkey baggageKey) bool {
+	return ctx.Value(sealKey(bkey)) != nil
+}
+
+// TryWithBaggage adds a baggage value like WithBaggage, but returns ErrSealed
+// instead of silently ignoring a write to a sealed key. It returns
+// ErrReservedKey for a key that begins with ReservedKeyPrefix. In strict
+// schema mode, it returns ErrNotInSchema for a key not registered with
+// RegisterSchema.
+func TryWithBaggage(ctx context.Context, key, value string) (context.Context, error) {
+	bkey := baggageKey(canonicalKey(key))
+	if strings.HasPrefix(string(bkey), ReservedKeyPrefix) {
+		return ctx, ErrReservedKey
+	}
+	if err := checkSchema(bkey); err != nil {
+		return ctx, err
+	}
+	if isSealed(ctx, bkey) {
+		return ctx, ErrSealed
+	}
+	return withBaggage(ctx, bkey, value), nil
+}
+
+// RequestIDKey is the baggage key for the request ID.
+const RequestIDKey = "request-id"
+
+// WithRequestID sets the request ID baggage if the context does not already
+// carry one, and seals it, so that an ID assigned at ingress survives every
+// downstream attempt to change it. If the context already carries a request
+// ID, such as one propagated from upstream, that ID is kept and sealed
+// instead.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if _, ok := RequestID(ctx); !ok {
+		ctx = WithBaggage(ctx, RequestIDKey, id)
+	}
+	if isSealed(ctx, baggageKey(canonicalKey(RequestIDKey))) {
+		return ctx
+	}
+	return Seal(ctx, RequestIDKey)
+}
+
+// RequestID returns the request ID baggage.
+func RequestID(ctx context.Context) (string, bool) {
+	return Baggage(ctx, RequestIDKey)
+}
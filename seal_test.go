@@ -0,0 +1,108 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestSeal(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Request-ID", "abc")
+	ctx = WithBaggage(ctx, "Shard", "a")
+	ctx = Seal(ctx, "Request-ID")
+	assert.True(t, Sealed(ctx, "request-id"))
+	assert.False(t, Sealed(ctx, "shard"))
+
+	ctx = WithBaggage(ctx, "Request-ID", "xyz")
+	ctx = WithBaggageJoin(ctx, "Request-ID", "xyz", joinReceipts)
+	ctx = WithBaggage(ctx, "Shard", "b")
+	id, _ := Baggage(ctx, "Request-ID")
+	assert.Equal(t, "abc", id)
+	shard, _ := Baggage(ctx, "Shard")
+	assert.Equal(t, "b", shard)
+}
+
+func TestTryWithBaggageSealed(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Request-ID", "abc")
+	ctx = Seal(ctx, "Request-ID")
+
+	out, err := TryWithBaggage(ctx, "Request-ID", "xyz")
+	assert.Equal(t, ErrSealed, err)
+	id, _ := Baggage(out, "Request-ID")
+	assert.Equal(t, "abc", id)
+
+	out, err = TryWithBaggage(ctx, "Shard", "a")
+	assert.NoError(t, err)
+	shard, _ := Baggage(out, "Shard")
+	assert.Equal(t, "a", shard)
+}
+
+func TestJoinSealed(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Request-ID", "abc")
+	ctx = Seal(ctx, "Request-ID")
+
+	downstream := WithBaggage(context.Background(), "Request-ID", "xyz")
+	downstream = WithBaggage(downstream, "Shard", "b")
+
+	joined := Join(ctx, downstream)
+	id, _ := Baggage(joined, "Request-ID")
+	assert.Equal(t, "abc", id)
+	shard, _ := Baggage(joined, "Shard")
+	assert.Equal(t, "b", shard)
+}
+
+func TestWithRequestID(t *testing.T) {
+	ctx := context.Background()
+	_, ok := RequestID(ctx)
+	assert.False(t, ok)
+
+	ctx = WithRequestID(ctx, "abc")
+	ctx = WithRequestID(ctx, "xyz")
+	id, ok := RequestID(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", id)
+
+	ctx = WithBaggage(ctx, "Request-ID", "xyz")
+	ctx = Remove(ctx, "Request-ID")
+	ctx = Join(ctx, WithBaggage(context.Background(), "Request-ID", "xyz"))
+	id, _ = RequestID(ctx)
+	assert.Equal(t, "abc", id)
+}
+
+func TestWithRequestIDFromUpstream(t *testing.T) {
+	h := http.Header{"Ctx-Request-Id": {"upstream"}}
+	ctx := DeserializeHeaders(context.Background(), h, "")
+
+	ctx = WithRequestID(ctx, "local")
+	ctx = WithBaggage(ctx, RequestIDKey, "overwritten")
+	ctx = Remove(ctx, RequestIDKey)
+	id, ok := RequestID(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "upstream", id)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.21
+
+// Package slogctx attaches Open Context baggage to log/slog records. It lives
+// in its own package so that only users of Go 1.21 and later take on log/slog.
+package slogctx
+
+import (
+	"log/slog"
+
+	"github.com/openctx/openctx-go"
+
+	"golang.org/x/net/context"
+)
+
+// A handler adds the baggage carried by the context of each record as
+// attributes before passing the record on.
+type handler struct {
+	next   slog.Handler
+	filter openctx.Filter
+	redact func(key string) bool
+}
+
+// SlogHandler returns a handler that adds the baggage carried by the context
+// of each record to the record as attributes, one per property named by its
+// baggage key, before passing the record to the next handler. Filters, such
+// as openctx.KeepPrefix, are applied from left to right to choose and rename
+// the properties that are logged, without affecting the context. The values
+// of keys that openctx.RedactSensitive selects, such as "authorization", are
+// logged as openctx.Redacted.
+func SlogHandler(next slog.Handler, filters ...openctx.Filter) slog.Handler {
+	return SlogHandlerRedact(next, openctx.RedactSensitive, filters...)
+}
+
+// SlogHandlerRedact returns a handler like SlogHandler that logs the values
+// of the keys selected by the redact function, after filtering, as
+// openctx.Redacted. If redact is nil, openctx.RedactSensitive is used.
+func SlogHandlerRedact(next slog.Handler, redact func(key string) bool, filters ...openctx.Filter) slog.Handler {
+	if redact == nil {
+		redact = openctx.RedactSensitive
+	}
+	return &handler{next: next, filter: openctx.Chain(filters...), redact: redact}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil && openctx.HasBaggage(ctx) {
+		ctx := h.filter(ctx)
+		keys := openctx.Keys(ctx)
+		if len(keys) > 0 {
+			attrs := make([]slog.Attr, len(keys))
+			for i, key := range keys {
+				value, _ := openctx.Baggage(ctx, key)
+				if h.redact(key) {
+					value = openctx.Redacted
+				}
+				attrs[i] = slog.String(key, value)
+			}
+			r = r.Clone()
+			r.AddAttrs(attrs...)
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{next: h.next.WithAttrs(attrs), filter: h.filter, redact: h.redact}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), filter: h.filter, redact: h.redact}
+}
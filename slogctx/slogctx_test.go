@@ -0,0 +1,97 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.21
+
+package slogctx
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/openctx/openctx-go"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func newTextHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+func newLogger(buf *bytes.Buffer, filters ...openctx.Filter) *slog.Logger {
+	return slog.New(SlogHandler(newTextHandler(buf), filters...))
+}
+
+func TestSlogHandler(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "Tenant", "acme")
+	ctx = openctx.WithBaggage(ctx, "Region", "us-east")
+
+	var buf bytes.Buffer
+	logger := newLogger(&buf)
+	logger.InfoContext(ctx, "served", "status", 200)
+	assert.Equal(t, "level=INFO msg=served status=200 region=us-east tenant=acme\n", buf.String())
+
+	buf.Reset()
+	logger.With("service", "api").WithGroup("req").InfoContext(ctx, "served")
+	assert.Equal(t, "level=INFO msg=served service=api req.region=us-east req.tenant=acme\n", buf.String())
+
+	buf.Reset()
+	logger.InfoContext(context.Background(), "idle")
+	assert.Equal(t, "level=INFO msg=idle\n", buf.String())
+}
+
+func TestSlogHandlerFilters(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "App-Tenant", "acme")
+	ctx = openctx.WithBaggage(ctx, "Token", "secret")
+
+	var buf bytes.Buffer
+	logger := newLogger(&buf, openctx.KeepPrefix("app-"), openctx.StripPrefix("app-"))
+	logger.InfoContext(ctx, "served")
+	assert.Equal(t, "level=INFO msg=served tenant=acme\n", buf.String())
+	token, _ := openctx.Baggage(ctx, "Token")
+	assert.Equal(t, "secret", token, "the context is unaffected")
+}
+
+func TestSlogHandlerRedacts(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "Tenant", "acme")
+	ctx = openctx.WithBaggage(ctx, "Authorization", "Bearer s3cr3t")
+
+	var buf bytes.Buffer
+	logger := newLogger(&buf)
+	logger.With("service", "api").InfoContext(ctx, "served")
+	assert.Equal(t, "level=INFO msg=served service=api authorization=[REDACTED] tenant=acme\n", buf.String())
+
+	buf.Reset()
+	redactTenant := func(key string) bool { return key == "tenant" }
+	slog.New(SlogHandlerRedact(newTextHandler(&buf), redactTenant)).InfoContext(ctx, "served")
+	assert.Equal(t, `level=INFO msg=served authorization="Bearer s3cr3t" tenant=[REDACTED]`+"\n", buf.String())
+}
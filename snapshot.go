@@ -0,0 +1,121 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"golang.org/x/net/context"
+)
+
+// A BaggageSnapshot records the baggage carried by a context at a point in
+// time, so that it can be restored after a scoped change. A snapshot is
+// immutable, so it may be handed to a template or another goroutine
+// independently of the lifecycle of the context it came from.
+type BaggageSnapshot struct {
+	baggage map[string]string
+	keys    []string
+}
+
+// Snapshot records the baggage carried by a context.
+func Snapshot(ctx context.Context) BaggageSnapshot {
+	keys := Keys(ctx)
+	baggage := make(map[string]string, len(keys))
+	for _, key := range keys {
+		baggage[key], _ = Baggage(ctx, key)
+	}
+	return BaggageSnapshot{baggage, keys}
+}
+
+// SnapshotOf returns a pointer to a snapshot of the baggage carried by a
+// context. It records the same baggage as Snapshot; the accessors have value
+// receivers, so either form may be used. The pointer form suits fields and
+// arguments where a nil snapshot means "no snapshot taken".
+func SnapshotOf(ctx context.Context) *BaggageSnapshot {
+	s := Snapshot(ctx)
+	return &s
+}
+
+// Get returns the value recorded for a key, and whether there is one.
+func (s BaggageSnapshot) Get(key string) (string, bool) {
+	value, ok := s.baggage[canonicalKey(key)]
+	return value, ok
+}
+
+// Keys returns the sorted key names recorded in the snapshot.
+func (s BaggageSnapshot) Keys() []string {
+	keys := make([]string, len(s.keys))
+	copy(keys, s.keys)
+	return keys
+}
+
+// Len returns the number of keys recorded in the snapshot.
+func (s BaggageSnapshot) Len() int {
+	return len(s.baggage)
+}
+
+// ToContext returns a context carrying the baggage in the snapshot, added in
+// sorted key order with WithBaggage, so that values are joined with any prior
+// values through the join functions in context. Unlike Restore, it leaves any
+// other baggage in context as it is.
+func (s BaggageSnapshot) ToContext(ctx context.Context) context.Context {
+	for _, key := range s.keys {
+		ctx = WithBaggage(ctx, key, s.baggage[key])
+	}
+	return ctx
+}
+
+// Restore returns a context carrying exactly the baggage in the snapshot:
+// keys added since the snapshot are removed and changed values are put back,
+// without consulting join functions. Sealed keys are left as they are.
+func (s BaggageSnapshot) Restore(ctx context.Context) context.Context {
+	var added []string
+	for _, key := range Keys(ctx) {
+		if _, ok := s.baggage[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	ctx = RemoveAll(ctx, added...)
+	for _, key := range s.keys {
+		value := s.baggage[key]
+		bkey := baggageKey(key)
+		if current, ok := lookup(ctx, bkey); (ok && current == value) || isSealed(ctx, bkey) {
+			continue
+		}
+		ctx = setBaggage(ctx, bkey, value)
+	}
+	return ctx
+}
+
+// ChangedSince returns the sorted baggage key names whose values differ
+// between a parent context and a child derived from it, including keys the
+// child added or removed. Middleware can use it to decide whether baggage
+// needs serializing again after a call.
+func ChangedSince(parent, child context.Context) []string {
+	changed := []string{}
+	for _, key := range UnionKeys(parent, child) {
+		bkey := baggageKey(key)
+		before, hadBefore := lookup(parent, bkey)
+		after, hasAfter := lookup(child, bkey)
+		if hadBefore != hasAfter || before != after {
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
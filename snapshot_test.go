@@ -0,0 +1,113 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithJoin(ctx, "receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "charlie")
+	ctx = WithTTL(ctx, time.Second)
+	ctx = WithBaggage(ctx, "Zone", "a")
+	snapshot := Snapshot(ctx)
+
+	scoped := WithReceipt(ctx, "alice")
+	scoped = WithBaggage(scoped, "Debug", "true")
+	scoped = WithBaggage(scoped, "Trace", "on")
+	scoped = Remove(scoped, "Zone")
+	assert.Equal(t, []string{"debug", "receipts", "trace", "ttl"}, Keys(scoped))
+
+	restored := snapshot.Restore(scoped)
+	assert.Equal(t, []string{"receipts", "ttl", "zone"}, Keys(restored))
+	assert.Equal(t, []string{"charlie"}, Receipts(restored))
+	zone, _ := Baggage(restored, "Zone")
+	assert.Equal(t, "a", zone)
+	assert.Equal(t, ctx, snapshot.Restore(ctx), "nothing to restore")
+}
+
+func TestSnapshotRestoreOrder(t *testing.T) {
+	ctx := context.Background()
+	for _, key := range []string{"e", "c", "a", "d", "b"} {
+		ctx = WithBaggage(ctx, key, "1")
+	}
+	snapshot := Snapshot(ctx)
+	for i := 0; i < 20; i++ {
+		restored := snapshot.Restore(context.Background())
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, KeysOrdered(restored))
+	}
+}
+
+func TestSnapshotOf(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Region", "us-east")
+	snapshot := SnapshotOf(ctx)
+
+	ctx = WithBaggage(ctx, "Region", "us-west")
+	ctx = WithBaggage(ctx, "Zone", "a")
+	ctx = Remove(ctx, "Tenant")
+
+	assert.Equal(t, 2, snapshot.Len())
+	keys := snapshot.Keys()
+	assert.Equal(t, []string{"region", "tenant"}, keys)
+	keys[0] = "mutated"
+	assert.Equal(t, []string{"region", "tenant"}, snapshot.Keys())
+	region, ok := snapshot.Get("Region")
+	assert.True(t, ok)
+	assert.Equal(t, "us-east", region)
+	tenant, ok := snapshot.Get("tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+	_, ok = snapshot.Get("Zone")
+	assert.False(t, ok)
+
+	restored := snapshot.ToContext(ctx)
+	assert.Equal(t, []string{"region", "tenant", "zone"}, Keys(restored))
+	region, _ = Baggage(restored, "Region")
+	assert.Equal(t, "us-east", region)
+
+	empty := SnapshotOf(context.Background())
+	assert.Equal(t, 0, empty.Len())
+	assert.Equal(t, []string{}, empty.Keys())
+	assert.Equal(t, ctx, empty.ToContext(ctx))
+}
+
+func TestChangedSince(t *testing.T) {
+	parent := context.Background()
+	parent = WithBaggage(parent, "Tenant", "acme")
+	parent = WithBaggage(parent, "Region", "us-east")
+	parent = WithBaggage(parent, "Token", "secret")
+	assert.Equal(t, []string{}, ChangedSince(parent, parent))
+
+	child := WithBaggage(parent, "Zone", "a")
+	child = WithBaggage(child, "Region", "us-west")
+	child = WithBaggage(child, "Tenant", "acme")
+	child = Remove(child, "Token")
+	assert.Equal(t, []string{"region", "token", "zone"}, ChangedSince(parent, child))
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Baggage may be appended to SQL queries as a sqlcommenter comment, a
+// comma-separated list of key='value' pairs, with keys and values
+// percent-encoded, in sorted key order, so that a database proxy or log can
+// attribute the query.
+
+// sqlEscape percent-encodes a string for a sqlcommenter comment. Spaces are
+// encoded as %20 rather than +, so that the result decodes as a path.
+func sqlEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// ToSQLComment returns the baggage carried by a context as a sqlcommenter
+// comment, such as /*tenant='acme'*/, to append to a query. It returns the
+// empty string if there is no baggage. As for header serialization, only keys
+// that the egress allow-list and DropEmptyValues permit are written, and hop
+// limits are counted down.
+func ToSQLComment(ctx context.Context) string {
+	entries := egressEntries(ctx)
+	if len(entries) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		pairs = append(pairs, sqlEscape(entry.Key)+"='"+sqlEscape(entry.Value)+"'")
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+// ParseSQLComment returns a context carrying the baggage from the sqlcommenter
+// comment that ends a query, joining each value with any prior value through
+// the join functions in context, and dropping values whose hop limit is used
+// up. If the query does not end with a comment, or the comment is malformed,
+// the context is returned unchanged.
+func ParseSQLComment(ctx context.Context, query string) context.Context {
+	query = strings.TrimRight(query, " \t\r\n;")
+	if !strings.HasSuffix(query, "*/") {
+		return ctx
+	}
+	query = query[:len(query)-len("*/")]
+	start := strings.LastIndex(query, "/*")
+	if start < 0 {
+		return ctx
+	}
+	comment := query[start+len("/*"):]
+	var keys, values []string
+	for _, pair := range strings.Split(comment, ",") {
+		i := strings.IndexByte(pair, '=')
+		if i < 0 {
+			return ctx
+		}
+		key, err := url.PathUnescape(pair[:i])
+		if err != nil || key == "" {
+			return ctx
+		}
+		value := pair[i+1:]
+		if len(value) < 2 || value[0] != '\'' || value[len(value)-1] != '\'' {
+			return ctx
+		}
+		value = strings.Replace(value[1:len(value)-1], `\'`, "'", -1)
+		value, err = url.PathUnescape(value)
+		if err != nil {
+			return ctx
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	for i, key := range keys {
+		ctx = WithBaggage(ctx, key, values[i])
+	}
+	return dropExhausted(ctx, keys)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestSQLCommentRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Route", "/users/{id}?x=1&y='2' 3+4")
+
+	comment := ToSQLComment(ctx)
+	assert.Equal(t, "/*route='%2Fusers%2F%7Bid%7D%3Fx%3D1%26y%3D%272%27%203%2B4',tenant='acme'*/", comment)
+
+	query := "SELECT * FROM users " + comment
+	got := ParseSQLComment(context.Background(), query)
+	assert.Equal(t, []string{"route", "tenant"}, Keys(got))
+	route, _ := Baggage(got, "Route")
+	assert.Equal(t, "/users/{id}?x=1&y='2' 3+4", route)
+
+	got = ParseSQLComment(context.Background(), query+";\n")
+	assert.Equal(t, []string{"route", "tenant"}, Keys(got))
+}
+
+func TestSQLCommentEmpty(t *testing.T) {
+	assert.Equal(t, "", ToSQLComment(context.Background()))
+}
+
+func TestParseSQLComment(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+	ctx = ParseSQLComment(ctx, `SELECT 1 /*receipts='bob',note='it\'s'*/`)
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(ctx))
+	note, _ := Baggage(ctx, "note")
+	assert.Equal(t, "it's", note)
+}
+
+func TestParseSQLCommentMalformed(t *testing.T) {
+	for _, query := range []string{
+		"SELECT 1",
+		"/*/",
+		"SELECT 1 /* plain comment */",
+		"SELECT 1 /*tenant=acme*/",
+		"SELECT 1 /*tenant='%zz'*/",
+		"SELECT 1 /*tenant='acme',region*/",
+	} {
+		ctx := ParseSQLComment(context.Background(), query)
+		assert.False(t, HasBaggage(ctx), query)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/net/context"
+)
+
+// The length-prefixed baggage format is a uvarint count of properties, then
+// for each property a uvarint length and the bytes of its key, followed by a
+// uvarint length and the bytes of its value.
+
+// maxFieldLen bounds the length of an encoded key or value, so that a corrupt
+// length prefix cannot force an arbitrarily large allocation.
+const maxFieldLen = 1 << 20
+
+// ErrFieldTooLong is returned when decoding a key or value whose length
+// prefix exceeds the limit for a baggage field.
+var ErrFieldTooLong = errors.New("openctx: encoded baggage field too long")
+
+// Marshaled baggage begins with a version byte, followed by the
+// length-prefixed encoding from Encode.
+const marshalVersion byte = 1
+
+// ErrUnsupportedVersion is returned by Unmarshal for marshaled baggage with
+// a version it does not recognize, such as baggage from a peer with a newer
+// codec, so that callers can route the bytes to another decoder.
+var ErrUnsupportedVersion = errors.New("openctx: unsupported marshaled baggage version")
+
+// Marshal returns the baggage carried by a context in the length-prefixed
+// format, preceded by a version byte.
+func Marshal(ctx context.Context) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(marshalVersion)
+	if err := Encode(ctx, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal returns a context carrying the baggage from the output of
+// Marshal, joining each value with any prior value through the join functions
+// in context. It returns ErrUnsupportedVersion if the version byte is not
+// recognized. On any error, no baggage is added.
+func Unmarshal(ctx context.Context, b []byte) (context.Context, error) {
+	if len(b) == 0 {
+		return ctx, io.ErrUnexpectedEOF
+	}
+	if b[0] != marshalVersion {
+		return ctx, ErrUnsupportedVersion
+	}
+	return Decode(ctx, bytes.NewReader(b[1:]))
+}
+
+// Encode writes the baggage carried by a context to a writer in the
+// length-prefixed format, property by property, without buffering the entire
+// encoding. As for header serialization, only keys that the egress allow-list
+// and DropEmptyValues permit are written, and hop limits are counted down.
+func Encode(ctx context.Context, w io.Writer) error {
+	entries := egressEntries(ctx)
+	var buf [binary.MaxVarintLen64]byte
+	if _, err := w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(entries)))]); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := writeField(w, buf[:], entry.Key); err != nil {
+			return err
+		}
+		if err := writeField(w, buf[:], entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeField(w io.Writer, buf []byte, field string) error {
+	if _, err := w.Write(buf[:binary.PutUvarint(buf, uint64(len(field)))]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, field)
+	return err
+}
+
+// Decode reads baggage in the length-prefixed format from a reader and
+// returns a context carrying it, joining each value with any prior value
+// through the join functions in context, and dropping values whose hop limit
+// is used up. Decode reads no further than the end of the encoded baggage. If
+// the input ends before the encoding is complete, Decode returns
+// io.ErrUnexpectedEOF. On any error, no baggage is added.
+func Decode(ctx context.Context, r io.Reader) (context.Context, error) {
+	br := byteReader{r}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return ctx, err
+	}
+	var keys, values []string
+	for i := uint64(0); i < n; i++ {
+		key, err := readField(br)
+		if err != nil {
+			return ctx, unexpectedEOF(err)
+		}
+		value, err := readField(br)
+		if err != nil {
+			return ctx, unexpectedEOF(err)
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	for i, key := range keys {
+		ctx = WithBaggage(ctx, key, values[i])
+	}
+	return dropExhausted(ctx, keys), nil
+}
+
+func readField(br byteReader) (string, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return "", err
+	}
+	if n > maxFieldLen {
+		return "", ErrFieldTooLong
+	}
+	field := make([]byte, n)
+	if _, err := io.ReadFull(br.r, field); err != nil {
+		return "", err
+	}
+	return string(field), nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// byteReader reads single bytes for uvarint decoding without the read-ahead
+// of a buffered reader, leaving the underlying reader positioned just past
+// the encoded baggage.
+type byteReader struct {
+	r io.Reader
+}
+
+func (br byteReader) ReadByte() (byte, error) {
+	if rb, ok := br.r.(io.ByteReader); ok {
+		return rb.ReadByte()
+	}
+	var b [1]byte
+	_, err := io.ReadFull(br.r, b[:])
+	return b[0], err
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithReceipt(ctx, "bob")
+	ctx = WithBaggage(ctx, "Note", "a=1, b=2\n")
+
+	var buf bytes.Buffer
+	assert.NoError(t, Encode(ctx, &buf))
+	assert.Equal(t, []byte("\x02\x04note\x09a=1, b=2\n\x08receipts\x0aalice, bob"), buf.Bytes())
+
+	buf.WriteString("trailing")
+	out, err := Decode(context.Background(), &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"note", "receipts"}, Keys(out))
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(out))
+	note, _ := Baggage(out, "note")
+	assert.Equal(t, "a=1, b=2\n", note)
+	assert.Equal(t, "trailing", buf.String(), "decode stops at the end of the baggage")
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBaggage(ctx, "Note", "a=1")
+
+	b, err := Marshal(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("\x01\x02\x04note\x03a=1\x08receipts\x05alice"), b)
+
+	out, err := Unmarshal(context.Background(), b)
+	assert.NoError(t, err)
+	assert.Equal(t, Dump(ctx), Dump(out))
+
+	future := append([]byte{2}, b[1:]...)
+	out, err = Unmarshal(ctx, future)
+	assert.Equal(t, ErrUnsupportedVersion, err)
+	assert.Equal(t, ctx, out)
+
+	_, err = Unmarshal(ctx, nil)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestDecodeOneByteReader(t *testing.T) {
+	ctx := WithReceipt(context.Background(), "alice")
+	var buf bytes.Buffer
+	assert.NoError(t, Encode(ctx, &buf))
+
+	out, err := Decode(context.Background(), iotest.OneByteReader(&buf))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, Receipts(out))
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBaggage(ctx, "Note", "hello")
+	var buf bytes.Buffer
+	assert.NoError(t, Encode(ctx, &buf))
+	encoded := buf.Bytes()
+
+	base := WithBaggage(context.Background(), "Tenant", "acme")
+	for n := 1; n < len(encoded); n++ {
+		out, err := Decode(base, bytes.NewReader(encoded[:n]))
+		assert.Equal(t, io.ErrUnexpectedEOF, err, "truncated to %d bytes", n)
+		assert.Equal(t, base, out, "no baggage is added when truncated to %d bytes", n)
+		out, err = Unmarshal(base, append([]byte{marshalVersion}, encoded[:n]...))
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Equal(t, base, out)
+	}
+	_, err := Decode(context.Background(), bytes.NewReader(nil))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeFieldTooLong(t *testing.T) {
+	_, err := Decode(context.Background(), bytes.NewReader([]byte{1, 0xff, 0xff, 0xff, 0xff, 0x0f}))
+	assert.Equal(t, ErrFieldTooLong, err)
+
+	base := WithBaggage(context.Background(), "Tenant", "acme")
+	out, err := Decode(base, bytes.NewReader([]byte{2, 1, 'a', 1, '1', 0xff, 0xff, 0xff, 0xff, 0x0f}))
+	assert.Equal(t, ErrFieldTooLong, err)
+	assert.Equal(t, base, out, "the entry before the invalid field is not added")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestEncodeWriteError(t *testing.T) {
+	ctx := WithReceipt(context.Background(), "alice")
+	assert.EqualError(t, Encode(ctx, failingWriter{}), "write failed")
+}
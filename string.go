@@ -0,0 +1,81 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// The string form of baggage is a single line of key=value pairs separated by
+// semicolons, in sorted key order, with keys and values escaped by
+// EncodeValue, which leaves no literal semicolons or equals signs within them.
+
+// String returns the baggage carried by a context in its string form, such as
+// "region=us-east;tenant=acme". Equal baggage always has the same string form.
+// As for header serialization, only keys that the egress allow-list and
+// DropEmptyValues permit are written, and hop limits are counted down.
+func String(ctx context.Context) string {
+	entries := egressEntries(ctx)
+	pairs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		pairs = append(pairs, EncodeValue(entry.Key)+"="+EncodeValue(entry.Value))
+	}
+	return strings.Join(pairs, ";")
+}
+
+// Parse returns a context carrying the baggage from its string form, joining
+// each value with any prior value through the join functions in context, and
+// dropping values whose hop limit is used up. It returns an error for a
+// malformed pair, in which case no baggage is added.
+func Parse(ctx context.Context, s string) (context.Context, error) {
+	if s == "" {
+		return ctx, nil
+	}
+	pairs := strings.Split(s, ";")
+	keys := make([]string, 0, len(pairs))
+	values := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		i := strings.IndexByte(pair, '=')
+		if i < 0 {
+			return ctx, fmt.Errorf("openctx: malformed baggage pair %q", pair)
+		}
+		key, err := DecodeValue(pair[:i])
+		if err != nil {
+			return ctx, err
+		}
+		if key == "" {
+			return ctx, fmt.Errorf("openctx: malformed baggage pair %q", pair)
+		}
+		value, err := DecodeValue(pair[i+1:])
+		if err != nil {
+			return ctx, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	for i, key := range keys {
+		ctx = WithBaggage(ctx, key, values[i])
+	}
+	return dropExhausted(ctx, keys), nil
+}
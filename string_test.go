@@ -0,0 +1,79 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/net/context"
+)
+
+func TestStringRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Note", "a=b; c, 100%\n")
+	ctx = WithBaggage(ctx, "Empty", "")
+
+	s := String(ctx)
+	assert.Equal(t, "empty=;note=a%3Db%3B c%2C 100%25%0A;tenant=acme", s)
+
+	out, err := Parse(context.Background(), s)
+	require.NoError(t, err)
+	assert.Equal(t, Keys(ctx), Keys(out))
+	for _, key := range Keys(ctx) {
+		want, _ := Baggage(ctx, key)
+		got, _ := Baggage(out, key)
+		assert.Equal(t, want, got, key)
+	}
+	assert.Equal(t, s, String(out))
+}
+
+func TestStringEmpty(t *testing.T) {
+	assert.Equal(t, "", String(context.Background()))
+	ctx, err := Parse(context.Background(), "")
+	require.NoError(t, err)
+	assert.False(t, HasBaggage(ctx))
+}
+
+func TestParseJoins(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+	ctx, err := Parse(ctx, "receipts=bob")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(ctx))
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, s := range []string{
+		"tenant",
+		"=acme",
+		"tenant=acme;;region=us",
+		"tenant=%zz",
+		"tenant=acme;region",
+	} {
+		ctx, err := Parse(context.Background(), s)
+		assert.Error(t, err, s)
+		assert.False(t, HasBaggage(ctx), s)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tchannelctx carries Open Context baggage over TChannel application
+// headers. TChannel headers are a plain string map and, by convention, carry
+// baggage without any prefix, so every header is treated as a baggage
+// property.
+package tchannelctx
+
+import (
+	"github.com/openctx/openctx-go"
+
+	"golang.org/x/net/context"
+)
+
+// ToTChannelHeaders returns the baggage carried by a context as TChannel
+// application headers, as written by openctx.OutboundEntries.
+func ToTChannelHeaders(ctx context.Context) map[string]string {
+	entries := openctx.OutboundEntries(ctx)
+	headers := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		headers[entry.Key] = entry.Value
+	}
+	return headers
+}
+
+// FromTChannelHeaders returns a context carrying the baggage from TChannel
+// application headers, joining each value with any prior value through the
+// join functions in context. Values whose hop limit is used up are dropped.
+func FromTChannelHeaders(ctx context.Context, headers map[string]string) context.Context {
+	for key, value := range headers {
+		ctx = openctx.WithBaggage(ctx, key, value)
+	}
+	return openctx.DropExhausted(ctx)
+}
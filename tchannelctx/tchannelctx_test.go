@@ -0,0 +1,76 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannelctx
+
+import (
+	"testing"
+
+	"github.com/openctx/openctx-go"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestToTChannelHeaders(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "Receipts", "alice")
+	ctx = openctx.WithBaggage(ctx, "TTL", "1000")
+	assert.Equal(t, map[string]string{
+		"receipts": "alice",
+		"ttl":      "1000",
+	}, ToTChannelHeaders(ctx))
+}
+
+func TestTChannelHeadersRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithBaggage(ctx, "Receipts", "alice")
+	ctx = openctx.WithBaggage(ctx, "TTL", "1000")
+	headers := ToTChannelHeaders(ctx)
+
+	out := FromTChannelHeaders(context.Background(), headers)
+	assert.Equal(t, []string{"receipts", "ttl"}, openctx.Keys(out))
+	assert.Equal(t, headers, ToTChannelHeaders(out))
+}
+
+func TestFromTChannelHeadersJoins(t *testing.T) {
+	ctx := context.Background()
+	ctx = openctx.WithJoin(ctx, "receipts", func(a, b string) string {
+		return a + ", " + b
+	})
+	ctx = openctx.WithBaggage(ctx, "receipts", "alice")
+	ctx = FromTChannelHeaders(ctx, map[string]string{"Receipts": "bob"})
+	receipts, ok := openctx.Baggage(ctx, "receipts")
+	assert.True(t, ok)
+	assert.Equal(t, "alice, bob", receipts)
+}
+
+func TestTChannelHeadersHopLimit(t *testing.T) {
+	ctx := openctx.WithHopLimit(context.Background(), "Debug", "verbose", 2)
+	ctx = openctx.WithBaggage(ctx, "Token", "secret")
+	ctx = openctx.WithEgressAllow(ctx, "Debug")
+	ctx = FromTChannelHeaders(context.Background(), ToTChannelHeaders(ctx))
+	assert.Equal(t, []string{"debug", "openctx.hops.debug"}, openctx.Keys(ctx))
+	hops, _ := openctx.HopsRemaining(ctx, "Debug")
+	assert.Equal(t, 1, hops)
+
+	ctx = FromTChannelHeaders(context.Background(), ToTChannelHeaders(ctx))
+	assert.Equal(t, []string{}, openctx.Keys(ctx))
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// TextMapCarrier is a set of string key value pairs that carries baggage over
+// a transport, such as HTTP headers or message attributes.
+type TextMapCarrier interface {
+	// Get returns the value for a key, or the empty string if there is none.
+	Get(key string) string
+	// Set sets the value for a key, replacing any prior value.
+	Set(key, value string)
+	// Keys returns the keys in the carrier.
+	Keys() []string
+}
+
+// HTTPHeaderCarrier adapts an http.Header as a TextMapCarrier. Get returns
+// only the first of repeated header values; DeserializeHeaders joins them all.
+type HTTPHeaderCarrier http.Header
+
+// Get returns the first value for a header.
+func (c HTTPHeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+// Set sets a header, replacing any prior values.
+func (c HTTPHeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Keys returns the header names.
+func (c HTTPHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// MapCarrier adapts a map of strings as a TextMapCarrier.
+type MapCarrier map[string]string
+
+// Get returns the value for a key.
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set sets the value for a key.
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys returns the keys of the map.
+func (c MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// InjectText writes the baggage carried by a context onto a carrier in the
+// same form as SerializeHeaders: one entry per property, named by the header
+// prefix configured with WithHeaderPrefix, or DefaultHeaderPrefix, and the
+// baggage key, with values escaped by EncodeValue. If the context carries an
+// egress allow-list from WithEgressAllow, only allowed keys are written.
+func InjectText(ctx context.Context, carrier TextMapCarrier) {
+	injectText(ctx, carrier, headerPrefix(ctx, ""))
+}
+
+func injectText(ctx context.Context, carrier TextMapCarrier, prefix string) {
+	for _, key := range egressKeys(ctx) {
+		value, _ := Baggage(ctx, key)
+		carrier.Set(prefix+key, EncodeValue(outboundValue(key, value)))
+	}
+}
+
+// ExtractText returns a context carrying the baggage from a carrier written by
+// InjectText, joining each value with any prior value through the join
+// functions in context. Keys match the prefix without regard to case. Entries
+// with malformed escapes are skipped, and an entry with the value
+// TombstoneMarker removes the key from the context.
+func ExtractText(ctx context.Context, carrier TextMapCarrier) context.Context {
+	prefix := headerPrefix(ctx, "")
+	var keys []string
+	for _, name := range carrier.Keys() {
+		key, ok := headerKey(name, prefix)
+		if !ok {
+			continue
+		}
+		keys = append(keys, key)
+		value := carrier.Get(name)
+		if value == TombstoneMarker {
+			ctx = Remove(ctx, key)
+			continue
+		}
+		value, err := DecodeValue(value)
+		if err != nil {
+			continue
+		}
+		ctx = WithBaggage(ctx, key, value)
+	}
+	return dropExhausted(ctx, keys)
+}
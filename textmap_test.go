@@ -0,0 +1,85 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestTextMapCarriers(t *testing.T) {
+	for _, carrier := range []TextMapCarrier{HTTPHeaderCarrier(http.Header{}), MapCarrier{}} {
+		ctx := context.Background()
+		ctx = WithBaggage(ctx, "Tenant", "acme")
+		ctx = WithBaggage(ctx, "Note", "a, b")
+		InjectText(ctx, carrier)
+		assert.Len(t, carrier.Keys(), 2)
+
+		out := ExtractText(context.Background(), carrier)
+		assert.Equal(t, []string{"note", "tenant"}, Keys(out))
+		note, _ := Baggage(out, "note")
+		assert.Equal(t, "a, b", note)
+	}
+}
+
+func TestMapCarrier(t *testing.T) {
+	ctx := WithHeaderPrefix(context.Background(), "baggage.")
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Note", "a, b")
+	carrier := MapCarrier{"other": "value"}
+	InjectText(ctx, carrier)
+	assert.Equal(t, MapCarrier{
+		"other":          "value",
+		"baggage.note":   "a%2C b",
+		"baggage.tenant": "acme",
+	}, carrier)
+	keys := carrier.Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"baggage.note", "baggage.tenant", "other"}, keys)
+}
+
+func TestExtractText(t *testing.T) {
+	ctx := WithJoin(context.Background(), "Receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = ExtractText(ctx, MapCarrier{
+		"Ctx-Receipts": "bob",
+		"ctx-tenant":   TombstoneMarker,
+		"ctx-bad":      "%zz",
+		"other":        "value",
+	})
+	assert.Equal(t, []string{"receipts"}, Keys(ctx))
+	assert.Equal(t, []string{"alice", "bob"}, Receipts(ctx))
+}
+
+func TestSerializeHeadersMatchesInjectText(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Tenant", "acme")
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+	carrier := HTTPHeaderCarrier(http.Header{})
+	InjectText(ctx, carrier)
+	assert.Equal(t, h, http.Header(carrier))
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestWithTime(t *testing.T) {
+	start := time.Date(2016, time.March, 14, 15, 9, 26, 535897932, time.FixedZone("PDT", -7*60*60))
+	ctx := WithTime(context.Background(), "Request-Start", start)
+	value, _ := Baggage(ctx, "request-start")
+	assert.Equal(t, "2016-03-14T15:09:26.535897932-07:00", value)
+
+	got, ok := Time(ctx, "Request-Start")
+	assert.True(t, ok)
+	assert.True(t, start.Equal(got))
+	assert.Equal(t, 535897932, got.Nanosecond())
+}
+
+func TestTimeInvalid(t *testing.T) {
+	_, ok := Time(context.Background(), "Request-Start")
+	assert.False(t, ok)
+
+	ctx := WithBaggage(context.Background(), "Request-Start", "yesterday")
+	_, ok = Time(ctx, "Request-Start")
+	assert.False(t, ok)
+}
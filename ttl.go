@@ -0,0 +1,77 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// TTLKey is the baggage key for a time to live, in milliseconds, that callers
+// propagate to bound the time spent on their behalf.
+const TTLKey = "ttl"
+
+// EffectiveTTL returns the time remaining to handle a request: the lesser of
+// the TTL baggage and the time until the context's deadline, so that callers
+// have one authoritative number. It returns false if the context has neither
+// a valid TTL nor a deadline. A deadline that has passed leaves no time.
+func EffectiveTTL(ctx context.Context) (time.Duration, bool) {
+	ttl, ok := ttlBaggage(ctx)
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		remaining := deadline.Sub(now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		if !ok || remaining < ttl {
+			ttl, ok = remaining, true
+		}
+	}
+	return ttl, ok
+}
+
+// ttlBaggage returns the TTL baggage as a duration, if it is a number of
+// milliseconds.
+func ttlBaggage(ctx context.Context) (time.Duration, bool) {
+	value, ok := Baggage(ctx, TTLKey)
+	if !ok {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// WithTTLDeadline returns a context that is canceled once the TTL baggage
+// elapses, and its cancel function, so that a service receiving a TTL bounds
+// its own processing. An earlier deadline on the context still applies. If
+// the context has no valid TTL, it is returned as is with a cancel function
+// that does nothing.
+func WithTTLDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	ttl, ok := ttlBaggage(ctx)
+	if !ok {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, now().Add(ttl))
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestEffectiveTTL(t *testing.T) {
+	_, restore := useFakeClock()
+	defer restore()
+	withDeadline := func(ctx context.Context, d time.Duration) context.Context {
+		ctx, _ = context.WithDeadline(ctx, now().Add(d))
+		return ctx
+	}
+
+	_, ok := EffectiveTTL(context.Background())
+	assert.False(t, ok)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want time.Duration
+	}{
+		{"ttl only", WithTTL(context.Background(), time.Second), time.Second},
+		{"deadline only", withDeadline(context.Background(), 2*time.Second), 2 * time.Second},
+		{"deadline tighter", withDeadline(WithTTL(context.Background(), time.Second), 500*time.Millisecond), 500 * time.Millisecond},
+		{"ttl tighter", withDeadline(WithTTL(context.Background(), time.Second), 3*time.Second), time.Second},
+		{"deadline passed", withDeadline(WithTTL(context.Background(), time.Second), -time.Second), 0},
+		{"invalid ttl", withDeadline(WithBaggage(context.Background(), TTLKey, "soon"), time.Second), time.Second},
+	}
+	for _, tt := range tests {
+		ttl, ok := EffectiveTTL(tt.ctx)
+		assert.True(t, ok, tt.name)
+		assert.Equal(t, tt.want, ttl, tt.name)
+	}
+}
+
+func TestWithTTLDeadline(t *testing.T) {
+	_, restore := useFakeClock()
+	defer restore()
+
+	ctx, cancel := WithTTLDeadline(WithTTL(context.Background(), 1500*time.Millisecond))
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, now().Add(1500*time.Millisecond), deadline)
+
+	ctx, cancel = WithTTLDeadline(WithBaggage(context.Background(), "Tenant", "acme"))
+	cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+	assert.Nil(t, ctx.Err())
+}
+
+func TestWithTTLDeadlineCancels(t *testing.T) {
+	ctx, cancel := WithTTLDeadline(WithTTL(context.Background(), time.Millisecond))
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	case <-time.After(time.Second):
+		t.Fatal("the TTL did not elapse")
+	}
+}
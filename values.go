@@ -0,0 +1,88 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// A multi-valued baggage property is carried as a single value: its distinct,
+// non-empty elements, each escaped with EncodeValue, sorted, and separated by
+// commas. Escaping leaves no literal commas within elements, and sorting gives
+// every set exactly one serialization.
+
+// WithBaggageValues adds elements to the set of values for a baggage key and
+// returns a new context. Sets are merged by JoinValueSet, which becomes the
+// join function for the key if the context has none, so that joined contexts
+// union their sets.
+func WithBaggageValues(ctx context.Context, key string, values ...string) context.Context {
+	return withOwnJoin(ctx, key, formatValueSet(values), JoinValueSet)
+}
+
+// BaggageValues returns the sorted set of values for a multi-valued baggage
+// key.
+func BaggageValues(ctx context.Context, key string) ([]string, bool) {
+	value, ok := Baggage(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	values := parseValueSet(value)
+	sort.Strings(values)
+	return values, true
+}
+
+// JoinValueSet joins two multi-valued baggage values by taking the union of
+// their elements.
+func JoinValueSet(a, b string) string {
+	return formatValueSet(append(parseValueSet(a), parseValueSet(b)...))
+}
+
+func parseValueSet(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	parts := strings.Split(value, ",")
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if element, err := DecodeValue(part); err == nil && element != "" {
+			values = append(values, element)
+		}
+	}
+	return values
+}
+
+func formatValueSet(values []string) string {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if value != "" {
+			set[EncodeValue(value)] = struct{}{}
+		}
+	}
+	elements := make([]string, 0, len(set))
+	for element := range set {
+		elements = append(elements, element)
+	}
+	sort.Strings(elements)
+	return strings.Join(elements, ",")
+}
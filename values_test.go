@@ -0,0 +1,76 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestBaggageValues(t *testing.T) {
+	ctx := context.Background()
+	_, ok := BaggageValues(ctx, "Services")
+	assert.False(t, ok)
+
+	ctx = WithBaggageValues(ctx, "Services", "charlie", "alice")
+	ctx = WithBaggageValues(ctx, "Services", "bob", "alice", "")
+	values, ok := BaggageValues(ctx, "Services")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"alice", "bob", "charlie"}, values)
+	value, _ := Baggage(ctx, "Services")
+	assert.Equal(t, "alice,bob,charlie", value)
+}
+
+func TestBaggageValuesWithSeparators(t *testing.T) {
+	ctx := WithBaggageValues(context.Background(), "Notes", "a, b", "c=d", "50%")
+	values, _ := BaggageValues(ctx, "Notes")
+	assert.Equal(t, []string{"50%", "a, b", "c=d"}, values)
+}
+
+func TestBaggageValuesJoin(t *testing.T) {
+	ctx := WithBaggageValues(context.Background(), "Services", "charlie")
+	ctxA := WithBaggageValues(ctx, "Services", "alice")
+	ctxB := WithBaggageValues(ctx, "Services", "bob", "alice")
+
+	values, _ := BaggageValues(JoinAll(ctx, ctxA, ctxB), "Services")
+	assert.Equal(t, []string{"alice", "bob", "charlie"}, values)
+	values, _ = BaggageValues(JoinAll(ctx, ctxB, ctxA), "Services")
+	assert.Equal(t, []string{"alice", "bob", "charlie"}, values)
+}
+
+func TestBaggageValuesSerialization(t *testing.T) {
+	ctx := WithBaggageValues(context.Background(), "Services", "bob", "a, b", "alice", "bob")
+	h := http.Header{}
+	SerializeHeaders(ctx, h, "")
+
+	again := http.Header{}
+	SerializeHeaders(WithBaggageValues(context.Background(), "Services", "alice", "a, b", "bob"), again, "")
+	assert.Equal(t, h, again, "serialization is deterministic")
+
+	out := DeserializeHeaders(context.Background(), h, "")
+	values, ok := BaggageValues(out, "Services")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a, b", "alice", "bob"}, values)
+}
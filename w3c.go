@@ -0,0 +1,118 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// The W3C baggage format carries all baggage in a single header value, as a
+// comma-separated list of key=value members. Keys are HTTP tokens. Values may
+// contain only printable ASCII other than spaces, double quotes, commas,
+// semicolons, and backslashes; all other bytes, and the percent sign itself,
+// are percent-encoded with the escaping of EncodeValue.
+
+// unsafeW3CValueByte reports whether a byte must be percent-encoded in a W3C
+// baggage value.
+func unsafeW3CValueByte(c byte) bool {
+	switch c {
+	case '"', ',', ';', '\\', '%':
+		return true
+	}
+	return c < 0x21 || c > 0x7e
+}
+
+// w3cKey reports whether a baggage key is a token, as defined by RFC 7230,
+// and so may be a W3C baggage member key.
+func w3cKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalW3CBaggage returns the baggage carried by a context in the W3C
+// baggage header format, with members in sorted key order. As for header
+// serialization, only keys that the egress allow-list and DropEmptyValues
+// permit are written, and hop limits are counted down. Keys that are not HTTP
+// tokens cannot be written in the format and are left out.
+func MarshalW3CBaggage(ctx context.Context) string {
+	return marshalW3C(egressEntries(ctx))
+}
+
+func marshalW3C(entries []Entry) string {
+	members := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !w3cKey(entry.Key) {
+			continue
+		}
+		members = append(members, entry.Key+"="+encodeBytes(entry.Value, unsafeW3CValueByte))
+	}
+	return strings.Join(members, ",")
+}
+
+// UnmarshalW3CBaggage returns a context carrying the baggage from a W3C
+// baggage header value, joining each value with any prior value through the
+// join functions in context. Member properties are ignored. It returns an
+// error for a malformed member, including one whose key is not an HTTP token,
+// in which case no baggage is added.
+func UnmarshalW3CBaggage(ctx context.Context, s string) (context.Context, error) {
+	var keys, values []string
+	for _, member := range strings.Split(s, ",") {
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		i := strings.IndexByte(member, '=')
+		if i < 0 {
+			return ctx, fmt.Errorf("openctx: malformed W3C baggage member %q", member)
+		}
+		key := strings.TrimSpace(member[:i])
+		if !w3cKey(key) {
+			return ctx, fmt.Errorf("openctx: malformed W3C baggage member %q", member)
+		}
+		value, err := DecodeValue(strings.TrimSpace(member[i+1:]))
+		if err != nil {
+			return ctx, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	for i, key := range keys {
+		ctx = WithBaggage(ctx, key, values[i])
+	}
+	return dropExhausted(ctx, keys), nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openctx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/context"
+)
+
+func TestMarshalW3CBaggage(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithReceipt(ctx, "alice")
+	ctx = WithReceipt(ctx, "bob")
+	ctx = WithBaggage(ctx, "Quote", `say "hi"; \ok`)
+	assert.Equal(t, `quote=say%20%22hi%22%3B%20%5Cok,receipts=alice%2C%20bob`, MarshalW3CBaggage(ctx))
+}
+
+// A literal percent sign must itself be escaped, or the receiver would fail
+// to decode "% o" as an escape.
+
+func TestW3CBaggagePercentRoundTrip(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "Promo", "50% off")
+	s := MarshalW3CBaggage(ctx)
+	assert.Equal(t, "promo=50%25%20off", s)
+
+	out, err := UnmarshalW3CBaggage(context.Background(), s)
+	assert.NoError(t, err)
+	promo, ok := Baggage(out, "Promo")
+	assert.True(t, ok)
+	assert.Equal(t, "50% off", promo)
+}
+
+func TestW3CBaggageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "a", "1,2;3=4")
+	ctx = WithBaggage(ctx, "b", "☃ snow\n")
+	ctx = WithBaggage(ctx, "c", "")
+
+	out, err := UnmarshalW3CBaggage(context.Background(), MarshalW3CBaggage(ctx))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, Keys(out))
+	for _, key := range Keys(ctx) {
+		want, _ := Baggage(ctx, key)
+		got, _ := Baggage(out, key)
+		assert.Equal(t, want, got, key)
+	}
+}
+
+func TestMarshalW3CBaggageEgress(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "Debug", "")
+	ctx = WithBaggage(ctx, "Token", "secret")
+	assert.Equal(t, "debug=,tenant=acme,token=secret", MarshalW3CBaggage(ctx), "empty values are kept by default")
+	assert.Equal(t, "debug=,tenant=acme", MarshalW3CBaggage(WithEgressAllow(ctx, "Tenant", "Debug")))
+
+	DropEmptyValues(true)
+	defer DropEmptyValues(false)
+	assert.Equal(t, "tenant=acme,token=secret", MarshalW3CBaggage(ctx))
+
+	h := http.Header{}
+	assert.NoError(t, W3CPropagator{}.Inject(ctx, h))
+	assert.Equal(t, "tenant=acme,token=secret", h.Get(W3CBaggageHeader))
+}
+
+func TestMarshalW3CBaggageKeys(t *testing.T) {
+	ctx := context.Background()
+	ctx = WithBaggage(ctx, "Tenant", "acme")
+	ctx = WithBaggage(ctx, "x-trace.id_1", "a")
+	ctx = WithBaggage(ctx, "a b", "space")
+	ctx = WithBaggage(ctx, "a=b", "equals")
+	ctx = WithBaggage(ctx, "a/b", "slash")
+	s := MarshalW3CBaggage(ctx)
+	assert.Equal(t, "tenant=acme,x-trace.id_1=a", s)
+
+	out, err := UnmarshalW3CBaggage(context.Background(), s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant", "x-trace.id_1"}, Keys(out))
+}
+
+func TestUnmarshalW3CBaggage(t *testing.T) {
+	ctx := WithJoin(context.Background(), "receipts", joinReceipts)
+	ctx = WithReceipt(ctx, "charlie")
+	ctx, err := UnmarshalW3CBaggage(ctx, " receipts = alice ;prop=1 , , Zone=a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "charlie"}, Receipts(ctx))
+	zone, _ := Baggage(ctx, "zone")
+	assert.Equal(t, "a", zone)
+
+	out, err := UnmarshalW3CBaggage(context.Background(), "")
+	assert.NoError(t, err)
+	assert.False(t, HasBaggage(out))
+}
+
+func TestUnmarshalW3CBaggageMalformed(t *testing.T) {
+	for _, s := range []string{"novalue", "=value", "promo=50% off", "a=1,b", "a b=1", "a/b=1", "caf\xc3\xa9=1"} {
+		out, err := UnmarshalW3CBaggage(context.Background(), s)
+		assert.Error(t, err, s)
+		assert.False(t, HasBaggage(out), s)
+	}
+}